@@ -0,0 +1,120 @@
+// Package config holds every setting of the server in one typed struct.
+//
+// Settings start from their defaults and are then read, each source winning
+// over the ones before it, from:
+//   - the YAML (.yaml, .yml) or TOML (.toml) file named by CONFIG_FILE,
+//   - a .env file in the working directory, when there is one,
+//   - environment variables.
+//
+// Every setting has a key in the file, such as server.port, and an
+// environment variable, such as PORT. Lists are separated by commas or spaces
+// in the environment. Run `config print` to see every setting.
+package config
+
+import "time"
+
+type Config struct {
+	Server    Server                  `config:"server"`
+	Database  Database                `config:"database"`
+	Auth      Auth                    `config:"auth"`
+	Passwords Passwords               `config:"passwords"`
+	Mail      Mail                    `config:"mail"`
+	SMTP      SMTP                    `config:"smtp"`
+	Reminders Reminders               `config:"reminders"`
+	Retention Retention               `config:"retention"`
+	OIDC      map[string]OIDCProvider `config:"oidc" env:"OIDC_PROVIDERS"` // Keyed by provider name
+}
+
+type Server struct {
+	Port              string        `config:"port" env:"PORT" default:"8080"`
+	AppURL            string        `config:"app_url" env:"APP_URL"` // Web app emailed links point to, tokens are emailed as is without it
+	ReadHeaderTimeout time.Duration `config:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" default:"10s"`
+	ReadTimeout       time.Duration `config:"read_timeout" env:"SERVER_READ_TIMEOUT" default:"30s"`
+	WriteTimeout      time.Duration `config:"write_timeout" env:"SERVER_WRITE_TIMEOUT" default:"60s"`
+	IdleTimeout       time.Duration `config:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" default:"2m"`
+	ShutdownTimeout   time.Duration `config:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" default:"30s"` // How long requests in flight get to finish on SIGTERM
+}
+
+type Database struct {
+	Driver string `config:"driver" env:"DB_DRIVER" default:"postgres"` // postgres, sqlite or memory
+	URL    string `config:"url" env:"DB_URL" secret:"true"`            // Postgres DSN, or the SQLite file path
+}
+
+type Auth struct {
+	AdminEmails          []string      `config:"admin_emails" env:"ADMIN_EMAILS"`
+	SigningAlgorithm     string        `config:"jwt_signing_alg" env:"JWT_SIGNING_ALG" default:"RS256"` // RS256, EdDSA or HS256
+	JWTSecretKey         string        `config:"jwt_secret_key" env:"JWT_SECRET_KEY" secret:"true"`     // Signs with HS256
+	AcceptLegacyHS256    bool          `config:"accept_legacy_hs256" env:"ACCEPT_LEGACY_HS256"`         // Keeps accepting HS256 tokens from JWT_SECRET_KEY after switching to RS256 or EdDSA
+	JWTIssuer            string        `config:"jwt_issuer" env:"JWT_ISSUER" default:"todo-backend"`    // iss claim of issued tokens
+	JWTAudience          string        `config:"jwt_audience" env:"JWT_AUDIENCE" default:"todo-api"`    // aud claim of issued tokens
+	KeyRotationInterval  time.Duration `config:"jwt_key_rotation_interval" env:"JWT_KEY_ROTATION_INTERVAL" default:"30d"`
+	KeyPublishAhead      time.Duration `config:"jwt_key_publish_ahead" env:"JWT_KEY_PUBLISH_AHEAD" default:"1h"`
+	AccessTokenTTL       time.Duration `config:"access_token_ttl" env:"ACCESS_TOKEN_TTL" default:"15m"`
+	RefreshTokenTTL      time.Duration `config:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL" default:"30d"`
+	EmailVerificationTTL time.Duration `config:"email_verification_ttl" env:"EMAIL_VERIFICATION_TTL" default:"24h"`
+	PasswordResetTTL     time.Duration `config:"password_reset_ttl" env:"PASSWORD_RESET_TTL" default:"1h"`
+	LoginMaxFailures     int           `config:"login_max_failures" env:"LOGIN_MAX_FAILURES" default:"5"`
+	LoginLockoutWindow   time.Duration `config:"login_lockout_window" env:"LOGIN_LOCKOUT_WINDOW" default:"15m"`
+	TOTPIssuer           string        `config:"totp_issuer" env:"TOTP_ISSUER" default:"Todo App"`
+}
+
+type Passwords struct {
+	Hasher            string   `config:"hasher" env:"PASSWORD_HASHER" default:"argon2id"` // argon2id or bcrypt
+	BcryptCost        int      `config:"bcrypt_cost" env:"BCRYPT_COST" default:"10"`
+	Argon2Memory      uint32   `config:"argon2_memory" env:"ARGON2_MEMORY" default:"65536"` // KiB
+	Argon2Iterations  uint32   `config:"argon2_iterations" env:"ARGON2_ITERATIONS" default:"3"`
+	Argon2Parallelism uint8    `config:"argon2_parallelism" env:"ARGON2_PARALLELISM" default:"2"`
+	Require           []string `config:"require" env:"PASSWORD_REQUIRE"`             // upper, lower, digit and symbol
+	BreachedList      string   `config:"breached_list" env:"PASSWORD_BREACHED_LIST"` // Have I Been Pwned file or range directory
+}
+
+type Mail struct {
+	Sender  string `config:"sender" env:"MAIL_SENDER" default:"log"` // log, file or smtp
+	FileDir string `config:"file_dir" env:"MAIL_FILE_DIR" default:"mail"`
+}
+
+// SMTP is the server account emails and reminders are sent through.
+type SMTP struct {
+	Host     string `config:"host" env:"SMTP_HOST" default:"localhost"`
+	Port     string `config:"port" env:"SMTP_PORT" default:"25"`
+	From     string `config:"from" env:"SMTP_FROM"`
+	Username string `config:"username" env:"SMTP_USERNAME"` // Leave empty for servers that do not require auth
+	Password string `config:"password" env:"SMTP_PASSWORD" secret:"true"`
+}
+
+type Reminders struct {
+	Notifier string        `config:"notifier" env:"REMINDER_NOTIFIER" default:"log"` // log or smtp
+	Interval time.Duration `config:"interval" env:"REMINDER_INTERVAL" default:"1m"`
+}
+
+type Retention struct {
+	Trash                time.Duration `config:"trash" env:"TRASH_RETENTION" default:"30d"`
+	TrashPurgeInterval   time.Duration `config:"trash_purge_interval" env:"TRASH_PURGE_INTERVAL" default:"1h"`
+	LoginAttempts        time.Duration `config:"login_attempts" env:"LOGIN_ATTEMPT_RETENTION" default:"90d"`
+	AccountDeletionGrace time.Duration `config:"account_deletion_grace" env:"ACCOUNT_DELETION_GRACE" default:"30d"`
+}
+
+// OIDCProvider is an identity provider users can log in with. Its
+// environment variables are prefixed with OIDC_<NAME>_, such as
+// OIDC_GOOGLE_CLIENT_ID.
+type OIDCProvider struct {
+	Issuer       string   `config:"issuer" env:"ISSUER"`
+	ClientID     string   `config:"client_id" env:"CLIENT_ID"`
+	ClientSecret string   `config:"client_secret" env:"CLIENT_SECRET" secret:"true"`
+	RedirectURL  string   `config:"redirect_url" env:"REDIRECT_URL"`
+	Scopes       []string `config:"scopes" env:"SCOPES"`
+}
+
+// Default returns the configuration with every setting at its default.
+func Default() *Config {
+	cfg := &Config{}
+	for _, setting := range settings(cfg) {
+		if setting.defaultValue == "" {
+			continue
+		}
+		if err := setting.set(setting.defaultValue); err != nil {
+			panic("config: default of " + setting.env + ": " + err.Error())
+		}
+	}
+	return cfg
+}
@@ -0,0 +1,301 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Error lists every problem found while loading the configuration.
+type Error struct {
+	Problems []string
+}
+
+func (err *Error) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(err.Problems, "\n  - ")
+}
+
+func (err *Error) add(format string, args ...interface{}) {
+	err.Problems = append(err.Problems, fmt.Sprintf(format, args...))
+}
+
+// setting is one value of the configuration.
+type setting struct {
+	key          string // Dotted path in the config file
+	env          string
+	defaultValue string
+	secret       bool
+	value        reflect.Value
+}
+
+// Load reads the configuration from its sources and validates it. When it
+// fails the returned *Error lists every problem, and the configuration is
+// still returned so it can be printed.
+func Load() (*Config, error) {
+	return load(".env", os.LookupEnv)
+}
+
+func load(envFile string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	cfg := Default()
+	problems := &Error{}
+
+	// Environment variables win over the .env file, which is optional
+	dotenv, err := godotenv.Read(envFile)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		problems.add("%s: %v", envFile, err)
+	}
+	lookup := func(name string) string {
+		if value, ok := lookupEnv(name); ok && value != "" {
+			return value
+		}
+		return dotenv[name]
+	}
+
+	if path := lookup("CONFIG_FILE"); path != "" {
+		if err := applyFile(cfg, path, problems); err != nil {
+			problems.add("%s: %v", path, err)
+		}
+	}
+	applyEnv(cfg, lookup, problems)
+	validate(cfg, problems)
+
+	if len(problems.Problems) > 0 {
+		return cfg, problems
+	}
+	return cfg, nil
+}
+
+// applyFile sets the values of a YAML or TOML file.
+func applyFile(cfg *Config, path string, problems *Error) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	values := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &values)
+	case ".toml":
+		err = toml.Unmarshal(content, &values)
+	default:
+		return fmt.Errorf("config file must be .yaml, .yml or .toml")
+	}
+	if err != nil {
+		return err
+	}
+
+	flat := map[string]interface{}{}
+	flatten(values, "", flat)
+
+	set := func(setting setting) {
+		value, ok := flat[setting.key]
+		if !ok {
+			return
+		}
+		delete(flat, setting.key)
+		if err := setting.setFromFile(value); err != nil {
+			problems.add("%s in %s %v", setting.key, path, err)
+		}
+	}
+	for _, setting := range settings(cfg) {
+		set(setting)
+	}
+
+	// Providers are named by their key under oidc
+	if providers, ok := values["oidc"].(map[string]interface{}); ok {
+		for name := range providers {
+			provider := cfg.OIDC[strings.ToLower(name)]
+			for _, setting := range providerSettings(name, &provider) {
+				set(setting)
+			}
+			setProvider(cfg, name, provider)
+		}
+	}
+
+	// Misspelled keys would otherwise be ignored without a word
+	var unknown []string
+	for key := range flat {
+		unknown = append(unknown, key)
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		problems.add("%s in %s is not a known setting", key, path)
+	}
+	return nil
+}
+
+// flatten collects the values of nested maps under their dotted key.
+func flatten(values map[string]interface{}, prefix string, flat map[string]interface{}) {
+	for key, value := range values {
+		if nested, ok := value.(map[string]interface{}); ok {
+			flatten(nested, prefix+key+".", flat)
+			continue
+		}
+		flat[prefix+key] = value
+	}
+}
+
+// applyEnv sets the values of environment variables.
+func applyEnv(cfg *Config, lookup func(string) string, problems *Error) {
+	for _, setting := range settings(cfg) {
+		if value := lookup(setting.env); value != "" {
+			if err := setting.set(value); err != nil {
+				problems.add("%s %v", setting.env, err)
+			}
+		}
+	}
+
+	for _, name := range splitList(lookup("OIDC_PROVIDERS")) {
+		provider := cfg.OIDC[strings.ToLower(name)]
+		for _, setting := range providerSettings(name, &provider) {
+			if value := lookup(setting.env); value != "" {
+				if err := setting.set(value); err != nil {
+					problems.add("%s %v", setting.env, err)
+				}
+			}
+		}
+		setProvider(cfg, name, provider)
+	}
+}
+
+func setProvider(cfg *Config, name string, provider OIDCProvider) {
+	if cfg.OIDC == nil {
+		cfg.OIDC = map[string]OIDCProvider{}
+	}
+	cfg.OIDC[strings.ToLower(name)] = provider
+}
+
+// settings lists the values of the configuration, apart from the OIDC
+// providers.
+func settings(cfg *Config) []setting {
+	return walk(reflect.ValueOf(cfg).Elem(), "", "")
+}
+
+// providerSettings lists the values of an OIDC provider.
+func providerSettings(name string, provider *OIDCProvider) []setting {
+	return walk(reflect.ValueOf(provider).Elem(), "oidc."+name, providerEnvPrefix(name))
+}
+
+// providerEnvPrefix is what the environment variables of a provider start
+// with, such as OIDC_GOOGLE_.
+func providerEnvPrefix(name string) string {
+	return "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+}
+
+func walk(value reflect.Value, key string, envPrefix string) []setting {
+	var all []setting
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Type().Field(i)
+		name := field.Tag.Get("config")
+		if key != "" {
+			name = key + "." + name
+		}
+
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			all = append(all, walk(value.Field(i), name, envPrefix)...)
+		case reflect.Map:
+			// Entries are listed by their own walk
+		default:
+			all = append(all, setting{
+				key:          name,
+				env:          envPrefix + field.Tag.Get("env"),
+				defaultValue: field.Tag.Get("default"),
+				secret:       field.Tag.Get("secret") == "true",
+				value:        value.Field(i),
+			})
+		}
+	}
+	return all
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// set parses raw into the setting. Lists are separated by commas or spaces.
+func (setting setting) set(raw string) error {
+	value := setting.value
+	raw = strings.TrimSpace(raw)
+
+	if value.Type() == durationType {
+		duration, err := parseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("must be a duration such as 90s, 15m, 24h or 30d, not %q", raw)
+		}
+		value.SetInt(int64(duration))
+		return nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(raw)
+	case reflect.Int:
+		number, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("must be a whole number, not %q", raw)
+		}
+		value.SetInt(int64(number))
+	case reflect.Bool:
+		enabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("must be true or false, not %q", raw)
+		}
+		value.SetBool(enabled)
+	case reflect.Uint8, reflect.Uint32:
+		number, err := strconv.ParseUint(raw, 10, value.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("must be a whole number up to %d, not %q", uint64(1)<<value.Type().Bits()-1, raw)
+		}
+		value.SetUint(number)
+	case reflect.Slice:
+		value.Set(reflect.ValueOf(splitList(raw)))
+	default:
+		return fmt.Errorf("has an unsupported type %s", value.Type())
+	}
+	return nil
+}
+
+// setFromFile sets a value decoded from the config file.
+func (setting setting) setFromFile(value interface{}) error {
+	items, isList := value.([]interface{})
+	if setting.value.Kind() == reflect.Slice && isList {
+		list := make([]string, 0, len(items))
+		for _, item := range items {
+			list = append(list, fmt.Sprint(item))
+		}
+		setting.value.Set(reflect.ValueOf(list))
+		return nil
+	}
+	if isList || value == nil {
+		return fmt.Errorf("must be a single value")
+	}
+	return setting.set(fmt.Sprint(value))
+}
+
+func splitList(raw string) []string {
+	return strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || unicode.IsSpace(r) })
+}
+
+// parseDuration parses a Go duration, or a number of days such as "30d".
+func parseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		count, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
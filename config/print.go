@@ -0,0 +1,114 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[redacted]"
+
+// Print writes the configuration as a YAML config file, with the environment
+// variable of every setting next to it and secrets redacted.
+func (cfg *Config) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	for _, setting := range settings(cfg) {
+		addSetting(root, setting)
+	}
+
+	names := make([]string, 0, len(cfg.OIDC))
+	for name := range cfg.OIDC {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		provider := cfg.OIDC[name]
+		for _, setting := range providerSettings(name, &provider) {
+			addSetting(root, setting)
+		}
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+// addSetting adds the setting under its dotted key, creating the sections
+// on the way.
+func addSetting(root *yaml.Node, setting setting) {
+	parts := strings.Split(setting.key, ".")
+	section := root
+	for _, part := range parts[:len(parts)-1] {
+		section = child(section, part)
+	}
+
+	value := formatValue(setting)
+	value.LineComment = setting.env
+	section.Content = append(section.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: parts[len(parts)-1]}, value)
+}
+
+// child returns the mapping under key, adding it when missing.
+func child(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, node)
+	return node
+}
+
+func formatValue(setting setting) *yaml.Node {
+	value := setting.value
+	if setting.secret && !value.IsZero() {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: redacted}
+	}
+
+	if value.Type() == durationType {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: formatDuration(time.Duration(value.Int()))}
+	}
+	switch value.Kind() {
+	case reflect.Int:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(value.Int(), 10)}
+	case reflect.Bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(value.Bool())}
+	case reflect.Uint8, reflect.Uint32:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatUint(value.Uint(), 10)}
+	case reflect.Slice:
+		list := &yaml.Node{Kind: yaml.SequenceNode, Style: yaml.FlowStyle}
+		for i := 0; i < value.Len(); i++ {
+			list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value.Index(i).String()})
+		}
+		return list
+	default:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fmt.Sprint(value.Interface())}
+	}
+}
+
+// formatDuration writes whole days as "30d", the way they are usually set.
+func formatDuration(duration time.Duration) string {
+	day := 24 * time.Hour
+	if duration >= day && duration%day == 0 {
+		return strconv.FormatInt(int64(duration/day), 10) + "d"
+	}
+
+	// 1h0m0s reads better as 1h
+	formatted := duration.String()
+	if strings.HasSuffix(formatted, "m0s") {
+		formatted = strings.TrimSuffix(formatted, "0s")
+	}
+	if strings.HasSuffix(formatted, "h0m") {
+		formatted = strings.TrimSuffix(formatted, "0m")
+	}
+	return formatted
+}
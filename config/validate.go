@@ -0,0 +1,139 @@
+package config
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// validate checks every setting, normalizing the ones that are case
+// insensitive, and adds what is wrong to problems.
+func validate(cfg *Config, problems *Error) {
+	all := settings(cfg)
+	invalid := func(field interface{}, format string, args ...interface{}) {
+		for _, setting := range all {
+			if setting.value.Addr().Interface() == field {
+				problems.add(setting.env+" ("+setting.key+") "+format, args...)
+				return
+			}
+		}
+		panic("config: validating a field that is not a setting")
+	}
+	oneOf := func(field *string, choices ...string) {
+		for _, choice := range choices {
+			if strings.EqualFold(*field, choice) {
+				*field = choice
+				return
+			}
+		}
+		invalid(field, "must be one of %s, not %q", strings.Join(choices, ", "), *field)
+	}
+	positive := func(field *time.Duration) {
+		if *field <= 0 {
+			invalid(field, "must be longer than zero")
+		}
+	}
+
+	// Server
+	if port, err := strconv.Atoi(cfg.Server.Port); err != nil || port < 1 || port > 65535 {
+		invalid(&cfg.Server.Port, "must be a port number, not %q", cfg.Server.Port)
+	}
+	if cfg.Server.AppURL != "" {
+		if appURL, err := url.Parse(cfg.Server.AppURL); err != nil || (appURL.Scheme != "http" && appURL.Scheme != "https") || appURL.Host == "" {
+			invalid(&cfg.Server.AppURL, "must be an http or https URL, not %q", cfg.Server.AppURL)
+		}
+	}
+	positive(&cfg.Server.ReadHeaderTimeout)
+	positive(&cfg.Server.ReadTimeout)
+	positive(&cfg.Server.WriteTimeout)
+	positive(&cfg.Server.IdleTimeout)
+	positive(&cfg.Server.ShutdownTimeout)
+
+	// Database
+	oneOf(&cfg.Database.Driver, "postgres", "sqlite", "memory")
+	if cfg.Database.Driver == "postgres" && cfg.Database.URL == "" {
+		invalid(&cfg.Database.URL, "is required for postgres")
+	}
+
+	// Tokens and logins
+	oneOf(&cfg.Auth.SigningAlgorithm, "RS256", "EdDSA", "HS256")
+	if cfg.Auth.SigningAlgorithm == "HS256" && cfg.Auth.JWTSecretKey == "" {
+		invalid(&cfg.Auth.JWTSecretKey, "is required to sign with HS256")
+	}
+	if cfg.Auth.AcceptLegacyHS256 && cfg.Auth.JWTSecretKey == "" {
+		invalid(&cfg.Auth.JWTSecretKey, "is required to accept legacy HS256 tokens")
+	}
+	if cfg.Auth.JWTIssuer == "" {
+		invalid(&cfg.Auth.JWTIssuer, "must not be empty")
+	}
+	if cfg.Auth.JWTAudience == "" {
+		invalid(&cfg.Auth.JWTAudience, "must not be empty")
+	}
+	positive(&cfg.Auth.KeyRotationInterval)
+	if cfg.Auth.KeyPublishAhead < 0 {
+		invalid(&cfg.Auth.KeyPublishAhead, "must not be negative")
+	}
+	positive(&cfg.Auth.AccessTokenTTL)
+	positive(&cfg.Auth.RefreshTokenTTL)
+	positive(&cfg.Auth.EmailVerificationTTL)
+	positive(&cfg.Auth.PasswordResetTTL)
+	if cfg.Auth.LoginMaxFailures <= 0 {
+		invalid(&cfg.Auth.LoginMaxFailures, "must be at least 1")
+	}
+	positive(&cfg.Auth.LoginLockoutWindow)
+	if cfg.Auth.TOTPIssuer == "" {
+		invalid(&cfg.Auth.TOTPIssuer, "must not be empty")
+	}
+
+	// Passwords
+	oneOf(&cfg.Passwords.Hasher, "argon2id", "bcrypt")
+	if cfg.Passwords.BcryptCost < 4 || cfg.Passwords.BcryptCost > 31 {
+		invalid(&cfg.Passwords.BcryptCost, "must be between 4 and 31")
+	}
+	if cfg.Passwords.Argon2Memory < 8 {
+		invalid(&cfg.Passwords.Argon2Memory, "must be at least 8 KiB")
+	}
+	if cfg.Passwords.Argon2Iterations == 0 {
+		invalid(&cfg.Passwords.Argon2Iterations, "must be at least 1")
+	}
+	if cfg.Passwords.Argon2Parallelism == 0 {
+		invalid(&cfg.Passwords.Argon2Parallelism, "must be at least 1")
+	}
+	for i, class := range cfg.Passwords.Require {
+		cfg.Passwords.Require[i] = strings.ToLower(class)
+		if !strings.Contains(" upper lower digit symbol ", " "+cfg.Passwords.Require[i]+" ") {
+			invalid(&cfg.Passwords.Require, "can only list upper, lower, digit and symbol, not %q", class)
+		}
+	}
+
+	// Mail and reminders
+	oneOf(&cfg.Mail.Sender, "log", "file", "smtp")
+	oneOf(&cfg.Reminders.Notifier, "log", "smtp")
+	if cfg.SMTP.From == "" && (cfg.Mail.Sender == "smtp" || cfg.Reminders.Notifier == "smtp") {
+		invalid(&cfg.SMTP.From, "is required to send through SMTP")
+	}
+	positive(&cfg.Reminders.Interval)
+
+	// Retention
+	positive(&cfg.Retention.Trash)
+	positive(&cfg.Retention.TrashPurgeInterval)
+	positive(&cfg.Retention.LoginAttempts)
+	if cfg.Retention.AccountDeletionGrace < 0 {
+		invalid(&cfg.Retention.AccountDeletionGrace, "must not be negative")
+	}
+
+	// Identity providers
+	names := make([]string, 0, len(cfg.OIDC))
+	for name := range cfg.OIDC {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if provider := cfg.OIDC[name]; provider.Issuer == "" || provider.ClientID == "" || provider.RedirectURL == "" {
+			prefix := providerEnvPrefix(name)
+			problems.add("%sISSUER, %sCLIENT_ID and %sREDIRECT_URL (oidc.%s) are required", prefix, prefix, prefix, name)
+		}
+	}
+}
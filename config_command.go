@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/Waris-Shaik/todo-backend/config"
+)
+
+const configUsage = `Usage: config <command>
+
+Commands:
+  print  show every setting as a YAML config file, with secrets redacted`
+
+// configCommand runs the config subcommand with its arguments.
+func configCommand(args []string) {
+	if len(args) != 1 || args[0] != "print" {
+		log.Fatal(configUsage)
+	}
+
+	// An invalid configuration is still printed, so the settings can be
+	// checked against the problems
+	cfg, invalid := config.Load()
+	if err := cfg.Print(os.Stdout); err != nil {
+		log.Fatal("Failed to print config: ", err)
+	}
+	if invalid != nil {
+		fmt.Fprintln(os.Stderr)
+		log.Fatal(invalid)
+	}
+}
@@ -0,0 +1,114 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func ExportAccount(ctx *gin.Context) {
+
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+	userData := user.(models.User)
+
+	archive, err := utils.BuildAccountExport(userData, NewSafeUser(userData))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to export account",
+		})
+		return
+	}
+
+	// Send it as a download
+	filename := fmt.Sprintf("todo-export-%s.zip", time.Now().UTC().Format("2006-01-02"))
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Data(http.StatusOK, "application/zip", archive)
+}
+
+func DeleteAccount(ctx *gin.Context) {
+
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+	userData := user.(models.User)
+
+	// Parse the request body
+	var body struct {
+		Password string `json:"password"`
+		Code     string `json:"code"` // Needed when two-factor authentication is on
+	}
+	if err := ctx.Bind(&body); err != nil || body.Password == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "please confirm your password",
+		})
+		return
+	}
+
+	// Accounts created through a login provider confirm with a password too
+	if userData.Password == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "set a password before deleting your account",
+		})
+		return
+	}
+	if err := utils.IsPasswordMatches(&body.Password, &userData.Password); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if userData.TOTPEnabledAt != nil {
+		if err := utils.VerifySecondFactor(userData, body.Code); err != nil {
+			ctx.JSON(twoFactorStatus(err), gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	dueAt, err := utils.ScheduleAccountDeletion(userData.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to delete account",
+		})
+		return
+	}
+	if err := utils.SendAccountDeletionNotice(ctx.Request.Context(), userData, dueAt); err != nil {
+		log.Printf("Failed to send deletion notice to user %d: %v", userData.ID, err)
+	}
+
+	// The account is signed out everywhere
+	utils.ClearCookies(ctx)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":         true,
+		"message":         "Your account will be deleted, log in again before then to keep it",
+		"deletion_due_at": dueAt,
+	})
+}
@@ -0,0 +1,281 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func AdminGetUsers(ctx *gin.Context) {
+	// Parse the filters
+	query, err := utils.ParseUserSearchQuery(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Count the matching users
+	var total int64
+	if result := query.Filter(initializers.DB.Model(&models.User{})).Count(&total); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to count users",
+		})
+		return
+	}
+
+	// Retreive the page of users
+	var users []models.User
+	result := query.Filter(initializers.DB).Order("id ASC").Limit(query.Limit).Offset(query.Offset).Find(&users)
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch users",
+		})
+		return
+	}
+
+	safeUsers := make([]SafeUser, 0, len(users))
+	for _, user := range users {
+		safeUsers = append(safeUsers, NewSafeUser(user))
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"users":   safeUsers,
+		"total":   total,
+	})
+}
+
+func AdminGetUser(ctx *gin.Context) {
+	// Retreive the user from the database
+	var user models.User
+	if result := initializers.DB.First(&user, ctx.Param("id")); result.Error != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"user":    NewSafeUser(user),
+	})
+}
+
+func AdminGetLoginAttempts(ctx *gin.Context) {
+	// Retreive the user from the database
+	var user models.User
+	if result := initializers.DB.First(&user, ctx.Param("id")); result.Error != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	attempts, err := utils.RecentLoginAttempts(user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch login attempts",
+		})
+		return
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"attempts": attempts,
+	})
+}
+
+func AdminDisableUser(ctx *gin.Context) {
+	setUserDisabled(ctx, true)
+}
+
+func AdminEnableUser(ctx *gin.Context) {
+	setUserDisabled(ctx, false)
+}
+
+func AdminSetUserRole(ctx *gin.Context) {
+	// Parse the request body
+	var body struct {
+		Role string `json:"role"`
+	}
+	if err := ctx.Bind(&body); err != nil || !models.IsValidRole(body.Role) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "role must be one of user, support, admin",
+		})
+		return
+	}
+
+	user, ok := findOtherUser(ctx)
+	if !ok {
+		return
+	}
+
+	// Update the role
+	if result := initializers.DB.Model(&user).Update("role", body.Role); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to update role",
+		})
+		return
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Role successfully updated",
+		"user":    NewSafeUser(user),
+	})
+}
+
+func AdminResetPassword(ctx *gin.Context) {
+	// Parse the request body
+	var body models.User
+	if err := ctx.Bind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Check password criteria matches
+	if err := utils.ValidatePassword(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	user, ok := findOtherUser(ctx)
+	if !ok {
+		return
+	}
+
+	// Hash the password
+	hashedPassword, err := utils.HashPassword(body.Password)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Store the new password, sign the user out everywhere and revoke their
+	// access tokens
+	if result := initializers.DB.Model(&user).Update("password", hashedPassword); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to reset password",
+		})
+		return
+	}
+	if err := utils.RevokeUserSessions(user.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to revoke sessions",
+		})
+		return
+	}
+	if err := utils.DeletePersonalAccessTokens(initializers.DB, user.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to revoke access tokens",
+		})
+		return
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Password successfully reset",
+	})
+}
+
+func setUserDisabled(ctx *gin.Context, disabled bool) {
+	user, ok := findOtherUser(ctx)
+	if !ok {
+		return
+	}
+
+	var disabledAt *time.Time
+	message := "User successfully enabled"
+	if disabled {
+		now := time.Now()
+		disabledAt = &now
+		message = "User successfully disabled"
+	}
+
+	// Update the account, disabling also ends every session and revokes the
+	// access tokens
+	if result := initializers.DB.Model(&user).Update("disabled_at", disabledAt); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to update user",
+		})
+		return
+	}
+	if disabled {
+		if err := utils.RevokeUserSessions(user.ID); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "failed to revoke sessions",
+			})
+			return
+		}
+		if err := utils.DeletePersonalAccessTokens(initializers.DB, user.ID); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "failed to revoke access tokens",
+			})
+			return
+		}
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"user":    NewSafeUser(user),
+	})
+}
+
+// findOtherUser loads the user named by the :id parameter, refusing the
+// admin's own account so an admin cannot lock themselves out.
+func findOtherUser(ctx *gin.Context) (models.User, bool) {
+	var user models.User
+	if result := initializers.DB.First(&user, ctx.Param("id")); result.Error != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return user, false
+	}
+
+	if admin, exists := ctx.Get("user"); exists && admin.(models.User).ID == user.ID {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "you cannot change your own account here",
+		})
+		return user, false
+	}
+
+	return user, true
+}
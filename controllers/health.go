@@ -0,0 +1,50 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/Waris-Shaik/todo-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Healthz tells the orchestrator the process is alive. It does not look at
+// dependencies, so a database outage does not get the server restarted.
+func Healthz(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "ok",
+	})
+}
+
+// Readyz tells load balancers whether the server can handle requests, by
+// checking the database and the other dependencies.
+func Readyz(ctx *gin.Context) {
+	failures := utils.CheckReadiness(ctx.Request.Context())
+
+	// Errors are only logged, the probe may be reachable from outside
+	checks := gin.H{}
+	for _, check := range utils.ReadinessChecks() {
+		if err, failed := failures[check.Name]; failed {
+			log.Printf("Readiness check %s failed: %v", check.Name, err)
+			checks[check.Name] = "failed"
+		} else {
+			checks[check.Name] = "ok"
+		}
+	}
+
+	if len(failures) > 0 {
+		ctx.JSON(http.StatusServiceUnavailable, gin.H{
+			"success": false,
+			"message": "not ready",
+			"checks":  checks,
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "ready",
+		"checks":  checks,
+	})
+}
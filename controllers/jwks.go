@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Waris-Shaik/todo-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// GetJWKS publishes the public token signing keys so other services can
+// verify our access tokens.
+func GetJWKS(ctx *gin.Context) {
+	jwks, err := utils.JWKS()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to load signing keys",
+		})
+		return
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, jwks)
+}
@@ -0,0 +1,307 @@
+package controllers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/oidc"
+	"github.com/Waris-Shaik/todo-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func GetOIDCProviders(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"providers": initializers.OIDC.Names(),
+	})
+}
+
+func OIDCLogin(ctx *gin.Context) {
+	beginOIDCLogin(ctx, nil)
+}
+
+func LinkIdentity(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	userID := user.(models.User).ID
+	beginOIDCLogin(ctx, &userID)
+}
+
+func OIDCCallback(ctx *gin.Context) {
+
+	// The provider reports a refused or failed login in the query string
+	if providerError := ctx.Query("error"); providerError != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "login was not completed at the provider: " + providerError,
+		})
+		return
+	}
+
+	state, code := ctx.Query("state"), ctx.Query("code")
+	if state == "" || code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "state and code are required",
+		})
+		return
+	}
+
+	// The login has to come back to the browser that started it, or anyone
+	// could send a victim a callback URL logging them in to the attacker's
+	// account, or linking the attacker's identity to theirs
+	if err := utils.CheckOIDCStateCookie(ctx, state); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Find or create the user behind the identity
+	user, err := utils.CompleteOIDCLogin(ctx.Request.Context(), ctx.Param("provider"), state, code)
+	if err != nil {
+		status := http.StatusUnauthorized
+		switch {
+		case errors.Is(err, oidc.ErrUnknownProvider):
+			status = http.StatusNotFound
+		case errors.Is(err, utils.ErrIdentityInUse), errors.Is(err, utils.ErrOIDCEmailTaken):
+			status = http.StatusConflict
+		case errors.Is(err, utils.ErrInvalidOIDCState):
+			status = http.StatusBadRequest
+		default:
+			log.Printf("OIDC login with %s failed: %v", ctx.Param("provider"), err)
+			err = errors.New("could not verify the login with the provider")
+		}
+		ctx.JSON(status, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	completeLogin(ctx, user)
+}
+
+func GetIdentities(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Retreive the linked identities
+	var identities []models.UserIdentity
+	if result := initializers.DB.Where("user_id = ?", user.(models.User).ID).Order("id ASC").Find(&identities); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch identities",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"identities": identities,
+	})
+}
+
+func DeleteIdentity(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+	userData := user.(models.User)
+
+	var identity models.UserIdentity
+	if result := initializers.DB.Where("id = ? AND user_id = ?", ctx.Param("id"), userData.ID).First(&identity); result.Error != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "identity not found",
+		})
+		return
+	}
+
+	// Keep at least one way to log in
+	var identities int64
+	initializers.DB.Model(&models.UserIdentity{}).Where("user_id = ?", userData.ID).Count(&identities)
+	if userData.Password == "" && identities <= 1 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "set a password before removing your last linked identity",
+		})
+		return
+	}
+
+	if result := initializers.DB.Delete(&identity); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to remove identity",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Identity successfully removed",
+	})
+}
+
+func SetPassword(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+	userData := user.(models.User)
+
+	// Accounts that have a password change it through their profile
+	if userData.Password != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "your account already has a password",
+		})
+		return
+	}
+
+	var body models.User
+	if err := ctx.Bind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err := utils.ValidatePassword(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Hash the password
+	hashedPassword, err := utils.HashPassword(body.Password)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if result := initializers.DB.Model(&userData).Update("password", hashedPassword); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to set password",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Password successfully set",
+	})
+}
+
+func RemovePassword(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+	userData := user.(models.User)
+
+	var body struct {
+		Password string `json:"password"`
+	}
+	if err := ctx.Bind(&body); err != nil || body.Password == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "please provide your current password",
+		})
+		return
+	}
+	if err := utils.IsPasswordMatches(&body.Password, &userData.Password); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Only accounts that can log in through a provider may drop the password
+	var identities int64
+	initializers.DB.Model(&models.UserIdentity{}).Where("user_id = ?", userData.ID).Count(&identities)
+	if identities == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "link an identity provider before removing your password",
+		})
+		return
+	}
+
+	if result := initializers.DB.Model(&userData).Update("password", ""); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to remove password",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Password successfully removed",
+	})
+}
+
+// beginOIDCLogin sends the user to the provider named in the URL.
+func beginOIDCLogin(ctx *gin.Context, linkUserID *uint) {
+	redirectURL, state, err := utils.BeginOIDCLogin(ctx.Request.Context(), ctx.Param("provider"), linkUserID)
+	if err != nil {
+		status := http.StatusBadGateway
+		message := "identity provider is not reachable"
+		if errors.Is(err, oidc.ErrUnknownProvider) {
+			status = http.StatusNotFound
+			message = err.Error()
+		} else {
+			log.Printf("OIDC login with %s failed: %v", ctx.Param("provider"), err)
+		}
+		ctx.JSON(status, gin.H{
+			"success": false,
+			"message": message,
+		})
+		return
+	}
+
+	utils.SendOIDCStateCookie(ctx, state)
+	ctx.Redirect(http.StatusFound, redirectURL)
+}
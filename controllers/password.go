@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+	"github.com/Waris-Shaik/todo-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func ForgotPassword(ctx *gin.Context) {
+
+	// Parse the request body
+	var body struct {
+		Email string `json:"email"`
+	}
+	if err := ctx.Bind(&body); err != nil || body.Email == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "please provide your email",
+		})
+		return
+	}
+
+	// Refuse while the email or this IP has asked too often, counting
+	// emails without an account the same way
+	if err := utils.CheckPasswordResetAllowed(ctx, body.Email); err != nil {
+		var limited *utils.PasswordResetLimitedError
+		if !errors.As(err, &limited) {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+		ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(limited.RetryAfter.Seconds()))))
+		ctx.JSON(http.StatusTooManyRequests, gin.H{
+			"success": false,
+			"message": limited.Error(),
+		})
+		return
+	}
+	if err := utils.RecordPasswordResetRequest(ctx, body.Email); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Only send mail to accounts that exist and are enabled, but answer the
+	// same way either way so the endpoint does not reveal who has an account.
+	// The email is sent in the background so the response takes as long
+	// either way too
+	user, err := repositories.FromContext(ctx).Users.FindByEmail(body.Email)
+	if err == nil && user.DisabledAt == nil {
+		sendCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx.Request.Context()), time.Minute)
+		go func() {
+			defer cancel()
+			if err := utils.SendPasswordReset(sendCtx, user); err != nil {
+				log.Printf("Failed to send password reset to user %d: %v", user.ID, err)
+			}
+		}()
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "If an account exists for that email, a reset link has been sent",
+	})
+}
+
+func ResetPassword(ctx *gin.Context) {
+
+	// Parse the request body
+	var body struct {
+		Token    string `json:"token"`
+		Password string `json:"password"`
+	}
+	if err := ctx.Bind(&body); err != nil || body.Token == "" || body.Password == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "please fill all required fields",
+		})
+		return
+	}
+
+	// Check password criteria matches
+	if err := utils.ValidatePassword(&models.User{Password: body.Password}); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Set the new password
+	if err := utils.ResetPassword(body.Token, body.Password); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, utils.ErrInvalidOneTimeToken) {
+			status = http.StatusBadRequest
+		}
+		ctx.JSON(status, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Drop this browser's cookies too, every session has been revoked
+	utils.ClearCookies(ctx)
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Password successfully reset, please login",
+	})
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func GetAccessTokens(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Retreive the user's tokens
+	var tokens []models.PersonalAccessToken
+	if result := initializers.DB.Where("user_id = ?", user.(models.User).ID).Order("created_at DESC").Find(&tokens); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch tokens",
+		})
+		return
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"tokens":  tokens,
+	})
+}
+
+func CreateAccessToken(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Parse the request body
+	var body struct {
+		Name      string   `json:"name"`
+		Scopes    []string `json:"scopes"`
+		ExpiresIn string   `json:"expires_in"` // e.g. "90d" or "12h", empty for no expiry
+	}
+	if err := ctx.Bind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Work out when the token expires
+	var expiresAt *time.Time
+	if body.ExpiresIn != "" {
+		expiresIn, err := utils.ParseDuration(body.ExpiresIn)
+		if err != nil || expiresIn <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "expires_in must look like 90d, 12h or 30m",
+			})
+			return
+		}
+		expiry := time.Now().Add(expiresIn)
+		expiresAt = &expiry
+	}
+
+	// Create the token
+	token, rawToken, err := utils.CreatePersonalAccessToken(user.(models.User).ID, body.Name, body.Scopes, expiresAt)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Return the raw token, this is the only time it is shown
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Token successfully created, copy it now as it will not be shown again",
+		"token":   rawToken,
+		"details": token,
+	})
+}
+
+func DeleteAccessToken(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Delete the token, only the user's own tokens can be deleted
+	result := initializers.DB.Where("id = ? AND user_id = ?", ctx.Param("id"), user.(models.User).ID).Delete(&models.PersonalAccessToken{})
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to delete token",
+		})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "token not found",
+		})
+		return
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Token successfully deleted",
+	})
+}
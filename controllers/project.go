@@ -0,0 +1,414 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+	"github.com/Waris-Shaik/todo-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func GetProjects(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Archived projects are only listed when asked for
+	query := initializers.DB.Where("user_id = ?", user.(models.User).ID)
+	if archived, _ := strconv.ParseBool(ctx.DefaultQuery("archived", "false")); !archived {
+		query = query.Where("archived = ?", false)
+	}
+
+	// Retreive the user's projects
+	var projects []models.Project
+	if result := query.Order("position ASC").Order("id ASC").Find(&projects); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch projects",
+		})
+		return
+	}
+
+	if err := utils.LoadProjectCounts(projects); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to count project todos",
+		})
+		return
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"projects": projects,
+	})
+}
+
+func GetProject(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Retreive the project from the database
+	project, err := utils.FindProjectForUser(ctx.Param("id"), user.(models.User).ID)
+	if err != nil {
+		ctx.JSON(projectLookupStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	projects := []models.Project{project}
+	if err := utils.LoadProjectCounts(projects); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to count project todos",
+		})
+		return
+	}
+
+	// Return the project in response
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"project": projects[0],
+	})
+}
+
+func CreateProject(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Parser user ID
+	userID := user.(models.User).ID
+
+	// Parse the request body to get project data
+	var body struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	}
+	if err := ctx.Bind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Check if requied fields are empty
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "project name is required",
+		})
+		return
+	}
+
+	// New projects go last
+	project := models.Project{Name: body.Name, Color: body.Color, UserID: userID}
+	var lastPosition *int
+	initializers.DB.Model(&models.Project{}).Where("user_id = ?", userID).Select("MAX(position)").Scan(&lastPosition)
+	if lastPosition != nil {
+		project.Position = *lastPosition + 1
+	}
+
+	// Create the project in the database
+	if result := initializers.DB.Create(&project); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to create project",
+		})
+		return
+	}
+
+	// Return the response
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "project successfully created",
+		"project": project,
+	})
+}
+
+func UpdateProject(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Retreive the project from the database
+	project, err := utils.FindProjectForUser(ctx.Param("id"), user.(models.User).ID)
+	if err != nil {
+		ctx.JSON(projectLookupStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Parse the request body to get edited data
+	var body struct {
+		Name     string `json:"name"`
+		Color    string `json:"color"`
+		Archived *bool  `json:"archived"`
+	}
+	if err := ctx.Bind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "invalid request body",
+		})
+		return
+	}
+
+	// Check if any changes made
+	changes := map[string]interface{}{}
+	if name := strings.TrimSpace(body.Name); name != "" {
+		changes["name"] = name
+	}
+	if body.Color != "" {
+		changes["color"] = body.Color
+	}
+	if body.Archived != nil {
+		changes["archived"] = *body.Archived
+	}
+	if len(changes) == 0 {
+		ctx.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "no changes were made",
+		})
+		return
+	}
+
+	// Update the project
+	if result := initializers.DB.Model(&project).Updates(changes); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to update project",
+		})
+		return
+	}
+
+	// Return the updated project in response
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "project successfully updated",
+		"project": project,
+	})
+}
+
+func ReorderProjects(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Parser user ID
+	userID := user.(models.User).ID
+
+	// Parse the request body to get the new order
+	var body struct {
+		ProjectIDs []uint `json:"project_ids"`
+	}
+	if err := ctx.Bind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// The new order must list every project exactly once
+	var currentIDs []uint
+	if result := initializers.DB.Model(&models.Project{}).Where("user_id = ?", userID).Pluck("id", &currentIDs); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch projects",
+		})
+		return
+	}
+	isProject := make(map[uint]bool, len(currentIDs))
+	for _, id := range currentIDs {
+		isProject[id] = true
+	}
+	valid := len(body.ProjectIDs) == len(currentIDs)
+	for _, id := range body.ProjectIDs {
+		if !isProject[id] {
+			valid = false
+		}
+		delete(isProject, id) // A repeated ID is then reported as unknown
+	}
+	if !valid {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "project_ids must list every project exactly once",
+		})
+		return
+	}
+
+	// Store the new positions
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		for position, id := range body.ProjectIDs {
+			if result := tx.Model(&models.Project{}).Where("id = ?", id).Update("position", position); result.Error != nil {
+				return result.Error
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to reorder projects",
+		})
+		return
+	}
+
+	// Return the response
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "projects successfully reordered",
+	})
+}
+
+func DeleteProject(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Parser user ID
+	userID := user.(models.User).ID
+
+	// Retreive the project from the database
+	project, err := utils.FindProjectForUser(ctx.Param("id"), userID)
+	if err != nil {
+		ctx.JSON(projectLookupStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// ?todos=move (the default) moves the todos to ?target=<project ID>, or
+	// out of any project without a target, while ?todos=cascade deletes them
+	var targetID *uint
+	mode := ctx.DefaultQuery("todos", "move")
+	switch mode {
+	case "cascade":
+	case "move":
+		if target := ctx.Query("target"); target != "" {
+			targetProject, err := utils.FindProjectForUser(target, userID)
+			if err != nil || targetProject.ID == project.ID {
+				ctx.JSON(http.StatusBadRequest, gin.H{
+					"success": false,
+					"message": "target project not found",
+				})
+				return
+			}
+			targetID = &targetProject.ID
+		}
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "todos must be move or cascade",
+		})
+		return
+	}
+
+	// Retreive the todos to delete along with their subtasks
+	var todoIDs []uint
+	if mode == "cascade" {
+		if result := initializers.DB.Model(&models.Todo{}).Where("project_id = ?", project.ID).Pluck("id", &todoIDs); result.Error != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "failed to fetch project todos",
+			})
+			return
+		}
+		for _, todoID := range todoIDs {
+			subtaskIDs, err := repositories.FromContext(ctx).Todos.DescendantIDs(todoID)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{
+					"success": false,
+					"message": "failed to fetch subtasks",
+				})
+				return
+			}
+			todoIDs = append(todoIDs, subtaskIDs...)
+		}
+	}
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if mode == "move" {
+			if result := tx.Model(&models.Todo{}).Where("project_id = ?", project.ID).Update("project_id", targetID); result.Error != nil {
+				return result.Error
+			}
+		} else if len(todoIDs) > 0 {
+			if result := tx.Where("id IN ?", todoIDs).Delete(&models.Todo{}); result.Error != nil {
+				return result.Error
+			}
+		}
+		return tx.Delete(&project).Error
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to delete project",
+		})
+		return
+	}
+
+	// Return the response
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "project successfully deleted",
+	})
+}
+
+// projectLookupStatus maps an error from utils.FindProjectForUser to a status
+// code.
+func projectLookupStatus(err error) int {
+	if errors.Is(err, utils.ErrProjectNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,217 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+	"github.com/Waris-Shaik/todo-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+const maxPreviewOccurrences = 50
+
+func PreviewOccurrences(ctx *gin.Context) {
+
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Retreive todo from the database
+	todo, _, err := utils.FindTodoForUser(repositories.FromContext(ctx).Todos, ctx.Param("id"), user.(models.User).ID)
+	if err != nil {
+		ctx.JSON(todoLookupStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Parse how many occurrences to list
+	count, err := strconv.Atoi(ctx.DefaultQuery("count", "5"))
+	if err != nil || count < 1 || count > maxPreviewOccurrences {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "count must be between 1 and 50",
+		})
+		return
+	}
+
+	if todo.Recurrence == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "todo is not recurring",
+		})
+		return
+	}
+
+	// Occurrences follow the owner's time zone
+	owner, err := repositories.FromContext(ctx).Users.FindByID(todo.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch todo owner",
+		})
+		return
+	}
+	location := owner.Location()
+
+	rule, err := utils.ParseRecurrenceRule(todo.Recurrence, location)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Return the upcoming due dates
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"recurrence":  todo.Recurrence,
+		"occurrences": rule.Occurrences(*todo.DueAt, max(todo.Occurrence, 1), count, location),
+	})
+}
+
+func SkipOccurrence(ctx *gin.Context) {
+
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Retreive todo from the database
+	todo, role, err := utils.FindTodoForUser(repositories.FromContext(ctx).Todos, ctx.Param("id"), user.(models.User).ID)
+	if err != nil {
+		ctx.JSON(todoLookupStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Check the user is allowed to change the todo
+	if !utils.CanEditTodo(role) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "you are not allowed to change this todo",
+		})
+		return
+	}
+
+	if todo.Recurrence == "" || todo.Completed {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "only open recurring todos can be skipped",
+		})
+		return
+	}
+
+	// Occurrences follow the owner's time zone
+	owner, err := repositories.FromContext(ctx).Users.FindByID(todo.UserID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch todo owner",
+		})
+		return
+	}
+
+	dueAt, ok, err := utils.NextOccurrence(todo, owner.Location())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "this is the last occurrence of the series",
+		})
+		return
+	}
+
+	// Move the todo on to the next occurrence
+	err = repositories.FromContext(ctx).Todos.Update(&todo, map[string]interface{}{
+		"due_at":      dueAt,
+		"remind_at":   utils.ShiftReminder(todo, dueAt),
+		"reminded_at": nil,
+		"occurrence":  max(todo.Occurrence, 1) + 1,
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to skip occurrence",
+		})
+		return
+	}
+
+	// Return the updated todo in response
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "occurrence successfully skipped",
+		"todo":    todo,
+	})
+}
+
+func StopRecurrence(ctx *gin.Context) {
+
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Retreive todo from the database
+	todo, role, err := utils.FindTodoForUser(repositories.FromContext(ctx).Todos, ctx.Param("id"), user.(models.User).ID)
+	if err != nil {
+		ctx.JSON(todoLookupStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Check the user is allowed to change the todo
+	if !utils.CanEditTodo(role) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "you are not allowed to change this todo",
+		})
+		return
+	}
+
+	// The series ends with this todo
+	if err := repositories.FromContext(ctx).Todos.Update(&todo, map[string]interface{}{"recurrence": ""}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to stop recurrence",
+		})
+		return
+	}
+
+	// Return the updated todo in response
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "recurrence successfully stopped",
+		"todo":    todo,
+	})
+}
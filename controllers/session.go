@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func GetSessions(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Retreive the user's active sessions
+	sessions, err := utils.ActiveSessions(user.(models.User).ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch sessions",
+		})
+		return
+	}
+
+	// Flag the session making this request
+	currentID := ctx.GetUint("session_id")
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentID
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"sessions": sessions,
+	})
+}
+
+func RevokeSession(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Retreive the session, only the user's own sessions can be revoked
+	var session models.Session
+	result := initializers.DB.Where("id = ? AND user_id = ? AND revoked_at IS NULL", ctx.Param("id"), user.(models.User).ID).First(&session)
+	if result.Error != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "session not found",
+		})
+		return
+	}
+
+	if err := utils.RevokeSession(session.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to revoke session",
+		})
+		return
+	}
+
+	// Signing out the current session also drops its cookies
+	if session.ID == ctx.GetUint("session_id") {
+		utils.ClearCookies(ctx)
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Session successfully revoked",
+	})
+}
+
+func RevokeOtherSessions(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Revoke every session except the one making this request
+	revoked, err := utils.RevokeOtherSessions(user.(models.User).ID, ctx.GetUint("session_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to revoke sessions",
+		})
+		return
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Signed out of all other sessions",
+		"revoked": revoked,
+	})
+}
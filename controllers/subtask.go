@@ -0,0 +1,247 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+	"github.com/Waris-Shaik/todo-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func CreateSubtask(ctx *gin.Context) {
+
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Retreive the parent todo from the database
+	parent, role, err := utils.FindTodoForUser(repositories.FromContext(ctx).Todos, ctx.Param("id"), user.(models.User).ID)
+	if err != nil {
+		ctx.JSON(todoLookupStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Check the user is allowed to change the todo
+	if !utils.CanEditTodo(role) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "you are not allowed to add subtasks to this todo",
+		})
+		return
+	}
+
+	// Check the subtask would not nest too deep
+	ancestorIDs, err := utils.TodoAncestorIDs(parent)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to create subtask",
+		})
+		return
+	}
+	if len(ancestorIDs)+1 > utils.MaxSubtaskDepth {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "subtasks cannot be nested any deeper",
+		})
+		return
+	}
+
+	// Parse the request body to get subtask data
+	var body struct {
+		Title       string          `json:"title"`
+		Description string          `json:"description"`
+		Priority    models.Priority `json:"priority"`
+		DueAt       *string         `json:"due_at"`
+	}
+	if err := ctx.Bind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Check if requied fields are empty
+	if body.Title == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "subtask title is required",
+		})
+		return
+	}
+
+	// Validate the due date in the user's time zone
+	creator := user.(models.User)
+	dueAt, _, err := utils.ParseTodoDates(body.DueAt, nil, creator.Location(), nil)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if body.Priority == 0 {
+		body.Priority = parent.Priority
+	}
+
+	// The subtask belongs to the owner of the todo and goes last
+	subtask := models.Todo{
+		Title:       body.Title,
+		Description: body.Description,
+		Priority:    body.Priority,
+		DueAt:       dueAt,
+		UserID:      parent.UserID,
+		ParentID:    &parent.ID,
+		Tags:        []models.Tag{},
+	}
+	todos := repositories.FromContext(ctx).Todos
+	siblings, err := todos.Subtasks([]uint{parent.ID}, user.(models.User).ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch subtasks",
+		})
+		return
+	}
+	for _, sibling := range siblings {
+		subtask.Position = max(subtask.Position, sibling.Position+1)
+	}
+
+	// Create the subtask in the database
+	if err := todos.Create(&subtask); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to create subtask",
+		})
+		return
+	}
+	subtask.User = parent.User
+
+	// Return the response
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "subtask successfully created",
+		"todo":    subtask,
+	})
+}
+
+func ReorderSubtasks(ctx *gin.Context) {
+
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Parser user ID
+	userID := user.(models.User).ID
+
+	// Retreive the parent todo from the database
+	parent, role, err := utils.FindTodoForUser(repositories.FromContext(ctx).Todos, ctx.Param("id"), userID)
+	if err != nil {
+		ctx.JSON(todoLookupStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Check the user is allowed to change the todo
+	if !utils.CanEditTodo(role) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "you are not allowed to reorder the subtasks of this todo",
+		})
+		return
+	}
+
+	// Parse the request body to get the new order
+	var body struct {
+		SubtaskIDs []uint `json:"subtask_ids"`
+	}
+	if err := ctx.Bind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// The new order must list every subtask exactly once
+	current, err := repositories.FromContext(ctx).Todos.Subtasks([]uint{parent.ID}, user.(models.User).ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch subtasks",
+		})
+		return
+	}
+	isSubtask := make(map[uint]bool, len(current))
+	for _, subtask := range current {
+		isSubtask[subtask.ID] = true
+	}
+	valid := len(body.SubtaskIDs) == len(current)
+	for _, id := range body.SubtaskIDs {
+		if !isSubtask[id] {
+			valid = false
+		}
+		delete(isSubtask, id) // A repeated ID is then reported as unknown
+	}
+	if !valid {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "subtask_ids must list every subtask of the todo exactly once",
+		})
+		return
+	}
+
+	// Store the new positions
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		for position, id := range body.SubtaskIDs {
+			if result := tx.Model(&models.Todo{}).Where("id = ?", id).Update("position", position); result.Error != nil {
+				return result.Error
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to reorder subtasks",
+		})
+		return
+	}
+
+	// Return the todo with its reordered subtasks
+	todos := []models.Todo{parent}
+	if err := utils.LoadSubtasks(repositories.FromContext(ctx).Todos, todos, userID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch subtasks",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "subtasks successfully reordered",
+		"todo":    todos[0],
+	})
+}
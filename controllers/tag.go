@@ -0,0 +1,305 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+	"github.com/Waris-Shaik/todo-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func GetTags(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Retreive the user's tags
+	var tags []models.Tag
+	result := initializers.DB.Where("user_id = ?", user.(models.User).ID).Order("name ASC").Find(&tags)
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch tags",
+		})
+		return
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"tags":    tags,
+	})
+}
+
+func CreateTag(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Parser user ID
+	userID := user.(models.User).ID
+
+	// Parse the request body to get tag data
+	var body struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	}
+	if err := ctx.Bind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Check if requied fields are empty
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "tag name is required",
+		})
+		return
+	}
+
+	// Tag names are unique per user
+	var count int64
+	initializers.DB.Model(&models.Tag{}).Where("user_id = ? AND name = ?", userID, body.Name).Count(&count)
+	if count > 0 {
+		ctx.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"message": "tag already exists",
+		})
+		return
+	}
+
+	// Create the tag in the database
+	tag := models.Tag{Name: body.Name, Color: body.Color, UserID: userID}
+	if result := initializers.DB.Create(&tag); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to create tag",
+		})
+		return
+	}
+
+	// Return the response
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "tag successfully created",
+		"tag":     tag,
+	})
+}
+
+func UpdateTag(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Parser user ID
+	userID := user.(models.User).ID
+
+	// Retreive the tag from the database
+	var tag models.Tag
+	if result := initializers.DB.Where("id = ? AND user_id = ?", ctx.Param("id"), userID).First(&tag); result.Error != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "tag not found",
+		})
+		return
+	}
+
+	// Parse the request body to get edited data
+	var body struct {
+		Name  string `json:"name"`
+		Color string `json:"color"`
+	}
+	if err := ctx.Bind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "invalid request body",
+		})
+		return
+	}
+
+	// Check if any changes made
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" && body.Color == "" {
+		ctx.JSON(http.StatusOK, gin.H{
+			"success": false,
+			"message": "no changes were made",
+		})
+		return
+	}
+
+	// Tag names are unique per user
+	if body.Name != "" && body.Name != tag.Name {
+		var count int64
+		initializers.DB.Model(&models.Tag{}).Where("user_id = ? AND name = ?", userID, body.Name).Count(&count)
+		if count > 0 {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"message": "tag already exists",
+			})
+			return
+		}
+	}
+
+	// Update the tag
+	if result := initializers.DB.Model(&tag).Updates(models.Tag{Name: body.Name, Color: body.Color}); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to update tag",
+		})
+		return
+	}
+
+	// Return the updated tag in response
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "tag successfully updated",
+		"tag":     tag,
+	})
+}
+
+func DeleteTag(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Retreive the tag from the database
+	var tag models.Tag
+	if result := initializers.DB.Where("id = ? AND user_id = ?", ctx.Param("id"), user.(models.User).ID).First(&tag); result.Error != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "tag not found",
+		})
+		return
+	}
+
+	// Detach the tag from its todos and delete it for good, so its name can
+	// be used again
+	if result := initializers.DB.Exec("DELETE FROM todo_tags WHERE tag_id = ?", tag.ID); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to delete tag",
+		})
+		return
+	}
+	if result := initializers.DB.Unscoped().Delete(&tag); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to delete tag",
+		})
+		return
+	}
+
+	// Return the response
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "tag successfully deleted",
+	})
+}
+
+func AttachTag(ctx *gin.Context) {
+	changeTodoTag(ctx, true)
+}
+
+func DetachTag(ctx *gin.Context) {
+	changeTodoTag(ctx, false)
+}
+
+// changeTodoTag attaches or detaches one of the user's tags to a todo they
+// can edit.
+func changeTodoTag(ctx *gin.Context, attach bool) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Parser user ID
+	userID := user.(models.User).ID
+
+	// Retreive todo from the database
+	todo, role, err := utils.FindTodoForUser(repositories.FromContext(ctx).Todos, ctx.Param("id"), userID)
+	if err != nil {
+		ctx.JSON(todoLookupStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Check the user is allowed to change the todo
+	if !utils.CanEditTodo(role) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "you are not allowed to tag this todo",
+		})
+		return
+	}
+
+	// Retreive the tag from the database
+	var tag models.Tag
+	if result := initializers.DB.Where("id = ? AND user_id = ?", ctx.Param("tagId"), userID).First(&tag); result.Error != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "tag not found",
+		})
+		return
+	}
+
+	// Update the association
+	association := initializers.DB.Model(&todo).Association("Tags")
+	if attach {
+		err = association.Append(&tag)
+	} else {
+		err = association.Delete(&tag)
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to update todo tags",
+		})
+		return
+	}
+
+	// Return the updated todo in response
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "todo tags successfully updated",
+		"todo":    todo,
+	})
+}
@@ -1,11 +1,15 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
-	"github.com/Waris-Shaik/todo-backend/initializers"
 	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+	"github.com/Waris-Shaik/todo-backend/utils"
 	"github.com/gin-gonic/gin"
 )
 
@@ -25,14 +29,19 @@ func CreateTodo(ctx *gin.Context) {
 	userID := user.(models.User).ID
 
 	// Parse the request body to get post data
-	var todo models.Todo
-	if err := ctx.Bind(&todo); err != nil {
+	var body struct {
+		models.Todo
+		DueAt    *string `json:"due_at"`
+		RemindAt *string `json:"remind_at"`
+	}
+	if err := ctx.Bind(&body); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"message": err.Error(),
 		})
 		return
 	}
+	todo := body.Todo
 
 	// Check if requied fields are empty
 	if todo.Title == "" || todo.Description == "" {
@@ -43,6 +52,57 @@ func CreateTodo(ctx *gin.Context) {
 		return
 	}
 
+	// Validate the due and reminder dates in the user's time zone
+	owner := user.(models.User)
+	dueAt, remindAt, err := utils.ParseTodoDates(body.DueAt, body.RemindAt, owner.Location(), nil)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	todo.DueAt = dueAt
+	todo.RemindAt = remindAt
+	todo.RemindedAt = nil
+
+	// New todos default to medium priority and start without tags. Subtasks
+	// are added through their own endpoint
+	if todo.Priority == 0 {
+		todo.Priority = models.PriorityMedium
+	}
+	todo.Tags = []models.Tag{}
+	todo.ParentID = nil
+	todo.Position = 0
+	todo.Subtasks = nil
+
+	// A recurring todo starts a new series
+	todo.Recurrence, err = utils.ValidateRecurrence(todo.Recurrence, todo.DueAt, owner.Location())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	todo.Occurrence = 0
+	if todo.Recurrence != "" {
+		todo.Occurrence = 1
+	}
+	todo.SeriesID = nil
+	todo.NextOccurrenceID = nil
+
+	// Check the project belongs to the user
+	if todo.ProjectID != nil {
+		if err := utils.ValidateTodoProject(*todo.ProjectID, userID); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
 	// Set the userID for the todo
 	todo.UserID = userID
 	userObj := models.UserLite{
@@ -53,8 +113,7 @@ func CreateTodo(ctx *gin.Context) {
 	todo.User = userObj
 
 	// Create the todo in the database
-	result := initializers.DB.Create(&todo)
-	if result.Error != nil {
+	if err := repositories.FromContext(ctx).Todos.Create(&todo); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"message": "failed to create todo",
@@ -85,27 +144,58 @@ func GetTodos(ctx *gin.Context) {
 	// Parser user ID
 	userID := user.(models.User).ID
 
-	// Retreive the todos
-	var todos []models.Todo
-	result := initializers.DB.Preload("User").Where("user_id = ?", userID).Find(&todos)
-	if result.Error != nil {
+	// Parse the filters, sorting and pagination
+	query, err := utils.ParseTodoListQuery(ctx, userID)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Retreive the page of todos and count every todo matching the filters
+	page, err := repositories.FromContext(ctx).Todos.List(userID, query)
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"message": "Failed to fetch todos",
-			"alert":   result.Error.Error(),
+			"alert":   err.Error(),
+		})
+		return
+	}
+	todos := page.Todos
+
+	// Embed the subtasks of each todo
+	if err := utils.LoadSubtasks(repositories.FromContext(ctx).Todos, todos, userID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch subtasks",
 		})
 		return
 	}
 
 	// Return the reponse
 	ctx.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"todos":   todos,
+		"success":     true,
+		"todos":       todos,
+		"total":       page.Total,
+		"next_cursor": page.NextCursor,
 	})
 }
 
 func GetSingleTodo(ctx *gin.Context) {
 
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
 	// Get the todoID from URL paramter
 	todoID := ctx.Param("id")
 
@@ -118,12 +208,21 @@ func GetSingleTodo(ctx *gin.Context) {
 	}
 
 	// Retreive todo from the database
-	var todo models.Todo
-	result := initializers.DB.Preload("User").First(&todo, todoID)
-	if result.Error != nil {
-		ctx.JSON(http.StatusOK, gin.H{
+	todo, role, err := utils.FindTodoForUser(repositories.FromContext(ctx).Todos, todoID, user.(models.User).ID)
+	if err != nil {
+		ctx.JSON(todoLookupStatus(err), gin.H{
 			"success": false,
-			"message": "todo not found",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Embed the subtasks
+	todos := []models.Todo{todo}
+	if err := utils.LoadSubtasks(repositories.FromContext(ctx).Todos, todos, user.(models.User).ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch subtasks",
 		})
 		return
 	}
@@ -131,12 +230,23 @@ func GetSingleTodo(ctx *gin.Context) {
 	// Return the retreived todo
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"todo":    todo,
+		"todo":    todos[0],
+		"role":    role,
 	})
 }
 
 func UpdateTodo(ctx *gin.Context) {
 
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
 	// Get todoIF from URL parameter
 	todoID := ctx.Param("id")
 
@@ -149,19 +259,55 @@ func UpdateTodo(ctx *gin.Context) {
 	}
 
 	// Retreive todo from the database
-	var todo models.Todo
-	result := initializers.DB.Preload("User").First(&todo, todoID)
-	if result.Error != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
+	todo, role, err := utils.FindTodoForUser(repositories.FromContext(ctx).Todos, todoID, user.(models.User).ID)
+	if err != nil {
+		ctx.JSON(todoLookupStatus(err), gin.H{
 			"success": false,
-			"message": "todo not found",
+			"message": err.Error(),
 		})
 		return
 	}
 
-	// toggle update
-	result = initializers.DB.Model(&todo).Update("completed", !todo.Completed)
-	if result.Error != nil {
+	// Check the user is allowed to change the todo
+	if !utils.CanEditTodo(role) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "you are not allowed to update this todo",
+		})
+		return
+	}
+
+	// With ?cascade=true the subtasks follow the new state of the todo
+	cascade, _ := strconv.ParseBool(ctx.DefaultQuery("cascade", "false"))
+	var subtaskIDs []uint
+	if cascade {
+		subtaskIDs, err = repositories.FromContext(ctx).Todos.DescendantIDs(todo.ID)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "failed to fetch subtasks",
+			})
+			return
+		}
+	}
+
+	// toggle update, completing a recurring todo creates its next occurrence
+	completed := !todo.Completed
+	var nextOccurrence *models.Todo
+	if completed {
+		owner, err := repositories.FromContext(ctx).Users.FindByID(todo.UserID)
+		if err == nil {
+			nextOccurrence, err = utils.NextOccurrenceTodo(todo, owner)
+		}
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "failed to update todo",
+			})
+			return
+		}
+	}
+	if err := repositories.FromContext(ctx).Todos.SetCompleted(&todo, completed, subtaskIDs, nextOccurrence); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"message": "failed to update todo",
@@ -169,17 +315,39 @@ func UpdateTodo(ctx *gin.Context) {
 		return
 	}
 
+	// Embed the subtasks
+	todos := []models.Todo{todo}
+	if err := utils.LoadSubtasks(repositories.FromContext(ctx).Todos, todos, user.(models.User).ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch subtasks",
+		})
+		return
+	}
+	todo = todos[0]
+
 	// Return the updated post
 	ctx.JSON(http.StatusOK, gin.H{
-		"success": true,
-		"message": "todo successfully updated",
-		"todo":    todo,
+		"success":         true,
+		"message":         "todo successfully updated",
+		"todo":            todo,
+		"next_occurrence": nextOccurrence,
 	})
 
 }
 
 func EditTodo(ctx *gin.Context) {
 
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
 	// Get the todoID from URL parameter
 	todoID := ctx.Param("id")
 	if todoID == "" {
@@ -191,18 +359,34 @@ func EditTodo(ctx *gin.Context) {
 	}
 
 	// Retreive todo from the database
-	var originalTodo models.Todo
-	result := initializers.DB.Preload("User").First(&originalTodo, todoID)
-	if result.Error != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
+	originalTodo, role, err := utils.FindTodoForUser(repositories.FromContext(ctx).Todos, todoID, user.(models.User).ID)
+	if err != nil {
+		ctx.JSON(todoLookupStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Check the user is allowed to change the todo
+	if !utils.CanEditTodo(role) {
+		ctx.JSON(http.StatusForbidden, gin.H{
 			"success": false,
-			"message": "todo not found",
+			"message": "you are not allowed to edit this todo",
 		})
 		return
 	}
 
 	// Parse the request body to get edited data
-	var editedTodo models.Todo
+	var editedTodo struct {
+		Title       string          `json:"title"`
+		Description string          `json:"description"`
+		Priority    models.Priority `json:"priority"`
+		DueAt       *string         `json:"due_at"`
+		RemindAt    *string         `json:"remind_at"`
+		Recurrence  *string         `json:"recurrence"`
+		ProjectID   *uint           `json:"project_id"` // 0 takes the todo out of its project
+	}
 	if err := ctx.Bind(&editedTodo); err != nil {
 		fmt.Println(err.Error())
 		ctx.JSON(http.StatusBadRequest, gin.H{
@@ -213,7 +397,7 @@ func EditTodo(ctx *gin.Context) {
 	}
 
 	// Check if any changes made
-	if editedTodo.Title == "" && editedTodo.Description == "" {
+	if editedTodo.Title == "" && editedTodo.Description == "" && editedTodo.Priority == 0 && editedTodo.DueAt == nil && editedTodo.RemindAt == nil && editedTodo.Recurrence == nil && editedTodo.ProjectID == nil {
 		ctx.JSON(http.StatusOK, gin.H{
 			"success": false,
 			"message": "no changes were made",
@@ -221,8 +405,85 @@ func EditTodo(ctx *gin.Context) {
 		return
 	}
 
-	result = initializers.DB.Model(&originalTodo).Updates(models.Todo{Title: editedTodo.Title, Description: editedTodo.Description})
-	if result.Error != nil {
+	// Validate the due and reminder dates in the user's time zone
+	editor := user.(models.User)
+	dueAt, remindAt, err := utils.ParseTodoDates(editedTodo.DueAt, editedTodo.RemindAt, editor.Location(), originalTodo.DueAt)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Check the recurrence still has a due date to start from
+	recurrence := originalTodo.Recurrence
+	if editedTodo.Recurrence != nil {
+		recurrence = *editedTodo.Recurrence
+	}
+	effectiveDueAt := originalTodo.DueAt
+	if editedTodo.DueAt != nil {
+		effectiveDueAt = dueAt
+	}
+	recurrence, err = utils.ValidateRecurrence(recurrence, effectiveDueAt, editor.Location())
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Only top-level todos go in projects, which must belong to the todo owner
+	if editedTodo.ProjectID != nil && *editedTodo.ProjectID != 0 {
+		if originalTodo.ParentID != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "subtasks cannot be moved to a project",
+			})
+			return
+		}
+		if err := utils.ValidateTodoProject(*editedTodo.ProjectID, originalTodo.UserID); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	// Only the sent fields are changed, an empty value clears it
+	changes := map[string]interface{}{}
+	if editedTodo.Title != "" {
+		changes["title"] = editedTodo.Title
+	}
+	if editedTodo.Description != "" {
+		changes["description"] = editedTodo.Description
+	}
+	if editedTodo.Priority != 0 {
+		changes["priority"] = editedTodo.Priority
+	}
+	if editedTodo.DueAt != nil {
+		changes["due_at"] = dueAt
+	}
+	if editedTodo.RemindAt != nil {
+		changes["remind_at"] = remindAt
+		changes["reminded_at"] = nil
+	}
+	if editedTodo.ProjectID != nil {
+		changes["project_id"] = editedTodo.ProjectID
+		if *editedTodo.ProjectID == 0 {
+			changes["project_id"] = nil
+		}
+	}
+	if editedTodo.Recurrence != nil {
+		changes["recurrence"] = recurrence
+		if recurrence != "" && originalTodo.Occurrence == 0 {
+			changes["occurrence"] = 1
+		}
+	}
+
+	if err := repositories.FromContext(ctx).Todos.Update(&originalTodo, changes); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"message": "failed to edit todo",
@@ -240,6 +501,16 @@ func EditTodo(ctx *gin.Context) {
 
 func DeleteTodo(ctx *gin.Context) {
 
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
 	// Get todoID from URL parameter
 
 	todoID := ctx.Param("id")
@@ -252,20 +523,43 @@ func DeleteTodo(ctx *gin.Context) {
 		return
 	}
 
+	// ?permanent=true skips the trash
+	if permanent, _ := strconv.ParseBool(ctx.DefaultQuery("permanent", "false")); permanent {
+		purgeTodo(ctx, user.(models.User).ID)
+		return
+	}
+
 	// Retreive todo from the database
-	var todo models.Todo
-	result := initializers.DB.Preload("User").First(&todo, todoID)
-	if result.Error != nil {
-		ctx.JSON(http.StatusBadRequest, gin.H{
+	todo, role, err := utils.FindTodoForUser(repositories.FromContext(ctx).Todos, todoID, user.(models.User).ID)
+	if err != nil {
+		ctx.JSON(todoLookupStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Only owners may delete a todo
+	if !utils.CanManageTodo(role) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "you are not allowed to delete this todo",
+		})
+		return
+	}
+
+	// Retreive the subtasks, they are deleted along with the todo
+	subtaskIDs, err := repositories.FromContext(ctx).Todos.DescendantIDs(todo.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
-			"message": "todo not found",
+			"message": "failed to fetch subtasks",
 		})
 		return
 	}
 
 	// Delete todo in the database
-	result = initializers.DB.Where("ID = ?", todoID).Delete(&todo)
-	if result.Error != nil {
+	if err := repositories.FromContext(ctx).Todos.Delete(append(subtaskIDs, todo.ID)); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"message": "failed to delete todo",
@@ -281,3 +575,84 @@ func DeleteTodo(ctx *gin.Context) {
 	})
 
 }
+
+// todoLookupStatus maps an error from utils.FindTodoForUser to a status code.
+func todoLookupStatus(err error) int {
+	if errors.Is(err, utils.ErrTodoNotFound) {
+		return http.StatusNotFound
+	}
+	return http.StatusInternalServerError
+}
+
+func GetOverdueTodos(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Parser user ID
+	userID := user.(models.User).ID
+
+	// Retreive the open todos whose due date has passed
+	todos, err := repositories.FromContext(ctx).Todos.ListDue(userID, nil, time.Now())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch overdue todos",
+		})
+		return
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"todos":   todos,
+	})
+}
+
+func GetUpcomingTodos(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Parser user ID
+	userID := user.(models.User).ID
+
+	// Parse how far ahead to look
+	within, err := utils.ParseWithin(ctx.DefaultQuery("within", "7d"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Retreive the open todos due within the window
+	now := time.Now()
+	todos, err := repositories.FromContext(ctx).Todos.ListDue(userID, &now, now.Add(within))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch upcoming todos",
+		})
+		return
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"todos":   todos,
+	})
+}
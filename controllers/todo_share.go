@@ -0,0 +1,301 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+	"github.com/Waris-Shaik/todo-backend/utils"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func GetSharedTodos(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Parser user ID
+	userID := user.(models.User).ID
+
+	// Retreive the todos shared with the user
+	var todos []models.Todo
+	result := initializers.DB.Preload("User").Preload("Tags", "user_id = ?", userID).
+		Joins("JOIN todo_shares ON todo_shares.todo_id = todos.id AND todo_shares.deleted_at IS NULL").
+		Where("todo_shares.user_id = ?", userID).
+		Find(&todos)
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch shared todos",
+		})
+		return
+	}
+
+	// Embed the subtasks of each todo
+	if err := utils.LoadSubtasks(repositories.FromContext(ctx).Todos, todos, userID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch subtasks",
+		})
+		return
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"todos":   todos,
+	})
+}
+
+func GetTodoShares(ctx *gin.Context) {
+
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Retreive todo from the database
+	todo, role, err := utils.FindTodoForUser(repositories.FromContext(ctx).Todos, ctx.Param("id"), user.(models.User).ID)
+	if err != nil {
+		ctx.JSON(todoLookupStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Only owners can see who the todo is shared with
+	if !utils.CanManageTodo(role) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "you are not allowed to view the shares of this todo",
+		})
+		return
+	}
+
+	// Retreive the shares along with the user they were granted to
+	var shares []models.TodoShare
+	result := initializers.DB.Model(&models.TodoShare{}).
+		Select("todo_shares.*, users.user_name, users.email").
+		Joins("JOIN users ON users.id = todo_shares.user_id").
+		Where("todo_shares.todo_id = ?", todo.ID).
+		Scan(&shares)
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch shares",
+		})
+		return
+	}
+
+	// Return the shares in response
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"shares":  shares,
+	})
+}
+
+func ShareTodo(ctx *gin.Context) {
+
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Retreive todo from the database
+	todo, role, err := utils.FindTodoForUser(repositories.FromContext(ctx).Todos, ctx.Param("id"), user.(models.User).ID)
+	if err != nil {
+		ctx.JSON(todoLookupStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Only owners can share the todo
+	if !utils.CanManageTodo(role) {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "you are not allowed to share this todo",
+		})
+		return
+	}
+
+	// Sharing sends the todo to other people, so it needs a verified email
+	if user.(models.User).EmailVerifiedAt == nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "please verify your email before sharing todos",
+		})
+		return
+	}
+
+	// Parse the request body to get the share data
+	var body struct {
+		UserName string `json:"username"`
+		Email    string `json:"email"`
+		Role     string `json:"role"`
+	}
+	if err := ctx.Bind(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Check if required fields are empty
+	if (body.UserName == "" && body.Email == "") || body.Role == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "please provide a username or email and a role",
+		})
+		return
+	}
+
+	// Check the role is known
+	body.Role = strings.ToLower(body.Role)
+	if !models.IsValidShareRole(body.Role) {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "role must be one of viewer, editor or owner",
+		})
+		return
+	}
+
+	// Retreive the user the todo is shared with
+	var sharedWith models.User
+	query := initializers.DB
+	if body.Email != "" {
+		query = query.Where("email = ?", body.Email)
+	} else {
+		query = query.Where("user_name = ?", body.UserName)
+	}
+	if result := query.First(&sharedWith); result.Error != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user to share with not found",
+		})
+		return
+	}
+
+	// The creator of the todo already owns it
+	if sharedWith.ID == todo.UserID {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "todo cannot be shared with its creator",
+		})
+		return
+	}
+
+	// Create the share or update the role of an existing one
+	var share models.TodoShare
+	result := initializers.DB.Where("todo_id = ? AND user_id = ?", todo.ID, sharedWith.ID).First(&share)
+	if result.Error != nil && !errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to share todo",
+		})
+		return
+	}
+	share.TodoID = todo.ID
+	share.UserID = sharedWith.ID
+	share.Role = body.Role
+	if result := initializers.DB.Save(&share); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to share todo",
+		})
+		return
+	}
+	share.UserName = sharedWith.UserName
+	share.Email = sharedWith.Email
+
+	// Return the share in response
+	ctx.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "todo successfully shared",
+		"share":   share,
+	})
+}
+
+func DeleteTodoShare(ctx *gin.Context) {
+
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Parser user ID
+	userID := user.(models.User).ID
+
+	// Retreive todo from the database
+	todo, role, err := utils.FindTodoForUser(repositories.FromContext(ctx).Todos, ctx.Param("id"), userID)
+	if err != nil {
+		ctx.JSON(todoLookupStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Retreive the share from the database
+	var share models.TodoShare
+	result := initializers.DB.Where("id = ? AND todo_id = ?", ctx.Param("shareId"), todo.ID).First(&share)
+	if result.Error != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "share not found",
+		})
+		return
+	}
+
+	// Owners can revoke any share, everyone else can only leave a todo
+	if !utils.CanManageTodo(role) && share.UserID != userID {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "you are not allowed to revoke this share",
+		})
+		return
+	}
+
+	// Delete the share in the database
+	if result := initializers.DB.Unscoped().Delete(&share); result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to revoke share",
+		})
+		return
+	}
+
+	// Return the response
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "share successfully revoked",
+	})
+}
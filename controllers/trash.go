@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+	"github.com/Waris-Shaik/todo-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func GetTrash(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Retreive the user's trashed todos. Subtasks trashed along with their
+	// parent are left out, they come back when the parent is restored
+	todos, err := repositories.FromContext(ctx).Todos.ListTrash(user.(models.User).ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "Failed to fetch trash",
+		})
+		return
+	}
+
+	// Return the reponse
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"todos":   todos,
+	})
+}
+
+func RestoreTodo(ctx *gin.Context) {
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Parser user ID
+	userID := user.(models.User).ID
+
+	// Retreive the trashed todo, only its owner can restore it
+	repos := repositories.FromContext(ctx)
+	todo, err := utils.FindOwnTodoWithTrashed(repos.Todos, ctx.Param("id"), userID)
+	if err != nil || !todo.DeletedAt.Valid {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "todo not found in trash",
+		})
+		return
+	}
+
+	// A subtask cannot come back while its parent is in the trash
+	if todo.ParentID != nil {
+		if _, err := repos.Todos.FindByID(*todo.ParentID, userID); err != nil {
+			ctx.JSON(http.StatusConflict, gin.H{
+				"success": false,
+				"message": "restore the parent todo first",
+			})
+			return
+		}
+	}
+
+	// Restore the todo with its subtasks, taking it out of its project when
+	// the project is gone
+	if err := repos.Todos.Restore(todo.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to restore todo",
+		})
+		return
+	}
+	if todo.ProjectID != nil {
+		if _, err := utils.FindProjectForUser(*todo.ProjectID, userID); err != nil {
+			if err := repos.Todos.Update(&todo, map[string]interface{}{"project_id": nil}); err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{
+					"success": false,
+					"message": "failed to restore todo",
+				})
+				return
+			}
+		}
+	}
+
+	// Return the restored todo in response
+	restored, _, err := utils.FindTodoForUser(repositories.FromContext(ctx).Todos, ctx.Param("id"), userID)
+	if err != nil {
+		ctx.JSON(todoLookupStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	todos := []models.Todo{restored}
+	if err := utils.LoadSubtasks(repositories.FromContext(ctx).Todos, todos, userID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch subtasks",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "todo successfully restored",
+		"todo":    todos[0],
+	})
+}
+
+// purgeTodo permanently deletes a todo of the user, trashed or not, with its
+// subtasks. It handles DELETE /api/v1/todos/:id?permanent=true.
+func purgeTodo(ctx *gin.Context, userID uint) {
+	// Retreive todo, including the trash. Only its owner can delete it for
+	// good
+	repos := repositories.FromContext(ctx)
+	todo, err := utils.FindOwnTodoWithTrashed(repos.Todos, ctx.Param("id"), userID)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "todo not found",
+		})
+		return
+	}
+
+	// Delete the todo with its subtasks
+	if err := repos.Todos.Purge(todo.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to delete todo",
+		})
+		return
+	}
+
+	// Return the deleted todo in response
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Todo permanently deleted",
+		"todo":    todo,
+	})
+}
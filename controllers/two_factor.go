@@ -0,0 +1,263 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/utils"
+	"github.com/gin-gonic/gin"
+)
+
+func LoginTwoFactor(ctx *gin.Context) {
+
+	// Parse the request body
+	var body struct {
+		MFAToken string `json:"mfa_token"`
+		Code     string `json:"code"`
+	}
+	if err := ctx.Bind(&body); err != nil || body.MFAToken == "" || body.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "please fill all required fields",
+		})
+		return
+	}
+
+	// Check the first step of the login happened
+	claims, err := utils.ParseMFAToken(body.MFAToken)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "login expired please login again",
+		})
+		return
+	}
+	userID, _ := utils.ClaimID(claims, "_id")
+
+	var user models.User
+	if result := initializers.DB.First(&user, userID); result.Error != nil || user.TOTPEnabledAt == nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "login expired please login again",
+		})
+		return
+	}
+	if user.DisabledAt != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": utils.ErrAccountDisabled.Error(),
+		})
+		return
+	}
+
+	// Codes count towards the same lockout as passwords
+	if err := utils.CheckLoginAllowed(ctx, user.Email); err != nil {
+		loginLockedResponse(ctx, err)
+		return
+	}
+
+	// Verify the code
+	if err := utils.VerifySecondFactor(user, body.Code); err != nil {
+		if errors.Is(err, utils.ErrInvalidTwoFactorCode) {
+			utils.RecordLoginAttempt(ctx, user.Email, &user.ID, utils.LoginFailureWrongCode)
+		}
+		ctx.JSON(twoFactorStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	finishLogin(ctx, user)
+}
+
+func EnrollTwoFactor(ctx *gin.Context) {
+
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Create the pending secret
+	secret, uri, err := utils.StartTOTPEnrollment(user.(models.User))
+	if err != nil {
+		ctx.JSON(twoFactorStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"message":     "Add the secret to your authenticator app, then confirm with a code",
+		"secret":      secret,
+		"otpauth_uri": uri,
+	})
+}
+
+func ConfirmTwoFactor(ctx *gin.Context) {
+
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Parse the request body
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := ctx.Bind(&body); err != nil || body.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "please provide a code from your authenticator app",
+		})
+		return
+	}
+
+	// Turn two-factor authentication on
+	codes, err := utils.ConfirmTOTPEnrollment(user.(models.User), body.Code)
+	if err != nil {
+		ctx.JSON(twoFactorStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"message":        "Two-factor authentication enabled, store the recovery codes somewhere safe as they will not be shown again",
+		"recovery_codes": codes,
+	})
+}
+
+func DisableTwoFactor(ctx *gin.Context) {
+
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+	userData := user.(models.User)
+
+	// Parse the request body
+	var body struct {
+		Password string `json:"password"`
+		Code     string `json:"code"`
+	}
+	if err := ctx.Bind(&body); err != nil || body.Password == "" || body.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "please provide your password and a code",
+		})
+		return
+	}
+
+	// Both the password and a code are needed to turn it off
+	if err := utils.IsPasswordMatches(&body.Password, &userData.Password); err != nil {
+		ctx.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+	if err := utils.VerifySecondFactor(userData, body.Code); err != nil {
+		ctx.JSON(twoFactorStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	if err := utils.DisableTOTP(userData.ID); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to disable two-factor authentication",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Two-factor authentication disabled",
+	})
+}
+
+func RegenerateRecoveryCodes(ctx *gin.Context) {
+
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+	userData := user.(models.User)
+
+	// Parse the request body
+	var body struct {
+		Code string `json:"code"`
+	}
+	if err := ctx.Bind(&body); err != nil || body.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "please provide a code from your authenticator app",
+		})
+		return
+	}
+
+	if err := utils.VerifySecondFactor(userData, body.Code); err != nil {
+		ctx.JSON(twoFactorStatus(err), gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Replace the old codes
+	codes, err := utils.ReplaceRecoveryCodes(initializers.DB, userData.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to create recovery codes",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":        true,
+		"message":        "New recovery codes created, the old ones no longer work",
+		"recovery_codes": codes,
+	})
+}
+
+// twoFactorStatus maps two-factor errors to a response status.
+func twoFactorStatus(err error) int {
+	switch {
+	case errors.Is(err, utils.ErrInvalidTwoFactorCode):
+		return http.StatusUnauthorized
+	case errors.Is(err, utils.ErrTwoFactorEnabled), errors.Is(err, utils.ErrTwoFactorNotEnabled), errors.Is(err, utils.ErrTwoFactorNotEnrolling):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -1,37 +1,78 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
-	"github.com/Waris-Shaik/todo-backend/initializers"
 	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
 	"github.com/Waris-Shaik/todo-backend/utils"
 	"github.com/gin-gonic/gin"
-	"golang.org/x/crypto/bcrypt"
 )
 
 type SafeUser struct {
-	ID        interface{} `json:"_id"`
-	Name      string      `json:"name"`
-	UserName  string      `json:"username"`
-	Email     string      `json:"email"`
-	CreatedAt time.Time   `json:"created_at"`
+	ID                 interface{} `json:"_id"`
+	Name               string      `json:"name"`
+	UserName           string      `json:"username"`
+	Email              string      `json:"email"`
+	TimeZone           string      `json:"time_zone"`
+	Role               string      `json:"role"`
+	DisabledAt         *time.Time  `json:"disabled_at,omitempty"`
+	EmailVerifiedAt    *time.Time  `json:"email_verified_at"`
+	TwoFactorEnabledAt *time.Time  `json:"two_factor_enabled_at"`
+	DeletionDueAt      *time.Time  `json:"deletion_due_at,omitempty"`
+	CreatedAt          time.Time   `json:"created_at"`
 	// Exclude Password field
 }
 
+// NewSafeUser copies the fields of user that are fine to show.
+func NewSafeUser(user models.User) SafeUser {
+	return SafeUser{
+		ID:                 user.ID,
+		Name:               user.Name,
+		UserName:           user.UserName,
+		Email:              user.Email,
+		TimeZone:           user.TimeZone,
+		Role:               user.Role,
+		DisabledAt:         user.DisabledAt,
+		EmailVerifiedAt:    user.EmailVerifiedAt,
+		TwoFactorEnabledAt: user.TOTPEnabledAt,
+		DeletionDueAt:      user.DeletionDueAt,
+		CreatedAt:          user.CreatedAt,
+	}
+}
+
 func SignUp(ctx *gin.Context) {
 
-	// Parse the request body to get user data
-	var user models.User
-	if err := ctx.Bind(&user); err != nil {
+	// Parse the request body to get user data. Only these fields can be set,
+	// so role, verification, 2FA and deletion state always start out empty
+	var body struct {
+		Name     string `json:"name"`
+		UserName string `json:"username"`
+		Email    string `json:"email"`
+		Password string `json:"password"`
+		TimeZone string `json:"time_zone"`
+	}
+	if err := ctx.Bind(&body); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"message": err.Error(),
 		})
 		return
 	}
+	user := models.User{
+		Name:     body.Name,
+		UserName: body.UserName,
+		Email:    body.Email,
+		Password: body.Password,
+		TimeZone: body.TimeZone,
+		Role:     models.RoleUser,
+	}
 
 	// Check if required fields are empty
 	if err := utils.ValidateUserData(&user); err != nil {
@@ -41,6 +82,13 @@ func SignUp(ctx *gin.Context) {
 		})
 		return
 	}
+	if err := utils.ValidateEmail(user.Email); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
 
 	// Check password criteria matches
 	if err := utils.ValidatePassword(&user); err != nil {
@@ -51,8 +99,20 @@ func SignUp(ctx *gin.Context) {
 		return
 	}
 
+	// Check the time zone, defaulting to UTC
+	if user.TimeZone == "" {
+		user.TimeZone = "UTC"
+	}
+	if err := utils.ValidateTimeZone(user.TimeZone); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
 	// Check if user exists
-	if err := utils.CheckExistingUser(user.Email); err != nil {
+	if err := utils.CheckExistingUser(repositories.FromContext(ctx).Users, user.Email); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"message": err.Error(),
@@ -61,7 +121,7 @@ func SignUp(ctx *gin.Context) {
 	}
 
 	// Hash the password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), 10)
+	hashedPassword, err := utils.HashPassword(user.Password)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -71,10 +131,10 @@ func SignUp(ctx *gin.Context) {
 	}
 
 	// Set the hashedPassword to user object
-	user.Password = string(hashedPassword)
+	user.Password = hashedPassword
 
 	// Store the user in database
-	if err := utils.CreateUser(&user); err != nil {
+	if err := utils.CreateUser(repositories.FromContext(ctx).Users, &user); err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success": false,
 			"message": err.Error(),
@@ -82,8 +142,8 @@ func SignUp(ctx *gin.Context) {
 		return
 	}
 
-	// Generate the token
-	token, err := utils.GenerateToken(&user)
+	// Start a session and generate its tokens
+	tokens, err := utils.StartSession(ctx, &user)
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{
 			"success":  false,
@@ -93,14 +153,18 @@ func SignUp(ctx *gin.Context) {
 	}
 
 	// Send the cookie 🍪
-	ctx.SetSameSite(http.SameSiteLaxMode)
-	ctx.SetCookie("token", token, int((time.Minute * 30).Seconds()), "", "", false, true)
+	utils.SendCookie(ctx, tokens.AccessToken, tokens.RefreshToken)
+
+	// Ask the user to confirm their email
+	if err := utils.SendEmailVerification(ctx.Request.Context(), user); err != nil {
+		log.Printf("Failed to send email verification to user %d: %v", user.ID, err)
+	}
 
 	// Return the created user
 	ctx.JSON(http.StatusCreated, gin.H{
 		"success": true,
-		"message": "User successfully created",
-		"user":    user,
+		"message": "User successfully created, please check your email to verify it",
+		"user":    NewSafeUser(user),
 	})
 
 }
@@ -129,55 +193,110 @@ func Login(ctx *gin.Context) {
 		return
 	}
 
-	// Check if user not exists
-	var user models.User
+	// Refuse while the account or this IP is locked out
+	if err := utils.CheckLoginAllowed(ctx, body.Email); err != nil {
+		loginLockedResponse(ctx, err)
+		return
+	}
 
-	result := initializers.DB.Where("email = ?", body.Email).First(&user)
-	if result.Error != nil {
-		ctx.JSON(http.StatusNotFound, gin.H{
+	// Check if user not exists, answering the same way as for a wrong
+	// password so the response does not tell who has an account
+	user, err := repositories.FromContext(ctx).Users.FindByEmail(body.Email)
+	if err != nil {
+		utils.CompareDummyPassword(body.Password)
+		utils.RecordLoginAttempt(ctx, body.Email, nil, utils.LoginFailureUnknownEmail)
+		ctx.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"message": "user not found please register",
+			"message": utils.ErrInvalidCredentials.Error(),
 		})
 		return
 	}
 
 	// Verify password
 	if err := utils.IsPasswordMatches(&body.Password, &user.Password); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		utils.RecordLoginAttempt(ctx, body.Email, &user.ID, utils.LoginFailureWrongPassword)
+		ctx.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"message": err.Error(),
+			"message": utils.ErrInvalidCredentials.Error(),
 		})
 		return
 	}
 
-	// Generate a JWT token
-	token, err := utils.GenerateToken(&user)
+	// Upgrade the stored hash if it was made with outdated settings
+	utils.RehashPassword(&user, body.Password)
+
+	completeLogin(ctx, user)
+
+}
+
+func Refresh(ctx *gin.Context) {
+
+	// Take the refresh token from the cookie, or from the body for clients
+	// that do not keep cookies
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	refreshToken, err := ctx.Cookie("refresh_token")
+	fromBody := false
+	if err != nil || refreshToken == "" {
+		if err := ctx.ShouldBindJSON(&body); err != nil || body.RefreshToken == "" {
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "refresh token is required please login",
+			})
+			return
+		}
+		refreshToken = body.RefreshToken
+		fromBody = true
+	}
+
+	// Rotate the refresh token
+	_, tokens, err := utils.RotateRefreshToken(ctx, refreshToken)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{
+		status := http.StatusInternalServerError
+		if errors.Is(err, utils.ErrInvalidRefreshToken) || errors.Is(err, utils.ErrRefreshTokenReused) || errors.Is(err, utils.ErrAccountDisabled) {
+			status = http.StatusUnauthorized
+			utils.ClearCookies(ctx)
+		}
+		ctx.JSON(status, gin.H{
 			"success": false,
 			"message": err.Error(),
 		})
 		return
 	}
-	fmt.Println("token is:", token)
-
-	// Set the cookie
-	ctx.SetSameSite(http.SameSiteLaxMode)
-	ctx.SetCookie("token", token, int((time.Minute * 30).Seconds()), "", "", false, true)
 
-	message := fmt.Sprintf("Welcome back %v", user.Name)
+	// Set the new cookies
+	utils.SendCookie(ctx, tokens.AccessToken, tokens.RefreshToken)
 
-	ctx.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"success": true,
-		"message": message,
-	})
-
+		"message": "Token refreshed",
+	}
+	if fromBody {
+		response["access_token"] = tokens.AccessToken
+		response["refresh_token"] = tokens.RefreshToken
+	}
+	ctx.JSON(http.StatusOK, response)
 }
 
 func Logout(ctx *gin.Context) {
 
-	// Remove the token in cookies
-	ctx.SetCookie("token", "", -1, "", "", false, true)
+	// Revoke the session so its tokens stop working, found through the
+	// refresh token or, failing that, the access token
+	if refreshToken, err := ctx.Cookie("refresh_token"); err == nil && refreshToken != "" {
+		if sessionID, ok := utils.SessionFromRefreshToken(refreshToken); ok {
+			utils.RevokeSession(sessionID)
+		}
+	} else if token := utils.RequestToken(ctx); token != "" {
+		if claims, err := utils.ParseToken(token, false); err == nil {
+			if sessionID, ok := utils.ClaimID(claims, "sid"); ok {
+				utils.RevokeSession(sessionID)
+			}
+		}
+	}
+
+	// Remove the tokens in cookies
+	utils.ClearCookies(ctx)
 
 	// Return the response
 	ctx.JSON(http.StatusOK, gin.H{
@@ -207,13 +326,7 @@ func Me(ctx *gin.Context) {
 	}
 
 	// Create an instance of SafeUser struct
-	safeUserData := SafeUser{
-		ID:        userData.ID,
-		Name:      userData.Name,
-		UserName:  userData.UserName,
-		Email:     userData.Email,
-		CreatedAt: userData.CreatedAt,
-	}
+	safeUserData := NewSafeUser(userData)
 
 	// Return the response
 	ctx.JSON(http.StatusOK, gin.H{
@@ -226,20 +339,25 @@ func Me(ctx *gin.Context) {
 func GetUsers(ctx *gin.Context) {
 
 	// Retreive users from the database
-	var users []models.User
-	result := initializers.DB.Find(&users)
-	if result.Error != nil {
+	users, err := repositories.FromContext(ctx).Users.List()
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
-			"message": result.Error.Error(),
+			"message": err.Error(),
 		})
 		return
 	}
 
+	// Strip the password hashes
+	safeUsers := make([]SafeUser, 0, len(users))
+	for _, user := range users {
+		safeUsers = append(safeUsers, NewSafeUser(user))
+	}
+
 	// Return the users in response
 	ctx.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"users":   users,
+		"users":   safeUsers,
 	})
 }
 
@@ -259,9 +377,9 @@ func UpdateUser(ctx *gin.Context) {
 	userID := user.(models.User).ID
 
 	// Retreive user from the database
-	var existingUser models.User
-	result := initializers.DB.First(&existingUser, userID)
-	if result.Error != nil {
+	users := repositories.FromContext(ctx).Users
+	existingUser, err := users.FindByID(userID)
+	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"message": "user not found",
@@ -269,18 +387,33 @@ func UpdateUser(ctx *gin.Context) {
 		return
 	}
 
-	// Parse the request body to get updated user
-	var updateUser models.User
-	if err := ctx.Bind(&updateUser); err != nil {
+	// Parse the request body to get updated user. Changing the email or
+	// password needs the current password too
+	var body struct {
+		Name            string `json:"name"`
+		UserName        string `json:"username"`
+		Email           string `json:"email"`
+		Password        string `json:"password"`
+		TimeZone        string `json:"time_zone"`
+		CurrentPassword string `json:"current_password"`
+	}
+	if err := ctx.Bind(&body); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"message": "failed to parse request body",
 		})
 		return
 	}
+	updateUser := models.User{
+		Name:     body.Name,
+		UserName: body.UserName,
+		Email:    body.Email,
+		Password: body.Password,
+		TimeZone: body.TimeZone,
+	}
 
 	// Check if required fields are not empty
-	if updateUser.Name == "" && updateUser.UserName == "" && updateUser.Email == "" && updateUser.Password == "" {
+	if updateUser.Name == "" && updateUser.UserName == "" && updateUser.Email == "" && updateUser.Password == "" && updateUser.TimeZone == "" {
 		ctx.JSON(http.StatusOK, gin.H{
 			"success": false,
 			"message": "no changes were made",
@@ -288,7 +421,54 @@ func UpdateUser(ctx *gin.Context) {
 		return
 	}
 
-	var showPassword string
+	// Check the new time zone is known
+	if updateUser.TimeZone != "" {
+		if err := utils.ValidateTimeZone(updateUser.TimeZone); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	// A new email has to look like one
+	emailChanged := updateUser.Email != "" && updateUser.Email != existingUser.Email
+	if emailChanged {
+		if err := utils.ValidateEmail(updateUser.Email); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
+	// Confirm it is the user before changing how they log in
+	if emailChanged || updateUser.Password != "" {
+		if existingUser.Password == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "set a password before changing your email or password",
+			})
+			return
+		}
+		if body.CurrentPassword == "" {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "please confirm your current password",
+			})
+			return
+		}
+		if err := utils.IsPasswordMatches(&body.CurrentPassword, &existingUser.Password); err != nil {
+			ctx.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+	}
+
 	// Check criteria meets or not
 	if updateUser.Password != "" && len(updateUser.Password) > 0 {
 		// Check password criteria matches
@@ -300,10 +480,8 @@ func UpdateUser(ctx *gin.Context) {
 			return
 		}
 
-		showPassword = updateUser.Password
-
 		// Hash the password
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(updateUser.Password), 10)
+		hashedPassword, err := utils.HashPassword(updateUser.Password)
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{
 				"success": false,
@@ -313,36 +491,73 @@ func UpdateUser(ctx *gin.Context) {
 		}
 
 		// Set the hashedPassword to updateUser.password
-		updateUser.Password = string(hashedPassword)
+		updateUser.Password = hashedPassword
+
+	}
 
+	// A new email has to be verified again
+	if emailChanged {
+		if err := utils.CheckExistingUser(users, updateUser.Email); err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": "email is already in use",
+			})
+			return
+		}
 	}
 
-	// Update the user object
-	result = initializers.DB.Model(&existingUser).Updates(models.User{Name: updateUser.Name, UserName: updateUser.UserName, Email: updateUser.Email, Password: updateUser.Password, UpdatedAt: time.Now()})
-	if result.Error != nil {
+	// Update the user object with the fields that were sent, and have a new
+	// email verified again
+	changes := map[string]interface{}{"updated_at": time.Now()}
+	for column, value := range map[string]string{
+		"name":      updateUser.Name,
+		"user_name": updateUser.UserName,
+		"email":     updateUser.Email,
+		"password":  updateUser.Password,
+		"time_zone": updateUser.TimeZone,
+	} {
+		if value != "" {
+			changes[column] = value
+		}
+	}
+	if err := users.Update(&existingUser, changes); err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
 			"message": "failed to update user",
 		})
 		return
 	}
+	if emailChanged {
+		if err := users.Update(&existingUser, map[string]interface{}{"email_verified_at": nil}); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": "failed to update user",
+			})
+			return
+		}
+		if err := utils.SendEmailVerification(ctx.Request.Context(), existingUser); err != nil {
+			log.Printf("Failed to send email verification to user %d: %v", existingUser.ID, err)
+		}
+	}
 
 	safeUserData := struct {
-		ID        uint      `json:"_id"`
-		Name      string    `json:"name"`
-		UserName  string    `json:"username"`
-		Email     string    `json:"email"`
-		Password  any       `json:"password"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
+		ID              uint       `json:"_id"`
+		Name            string     `json:"name"`
+		UserName        string     `json:"username"`
+		Email           string     `json:"email"`
+		TimeZone        string     `json:"time_zone"`
+		EmailVerifiedAt *time.Time `json:"email_verified_at"`
+		CreatedAt       time.Time  `json:"created_at"`
+		UpdatedAt       time.Time  `json:"updated_at"`
 	}{
-		ID:        existingUser.ID,
-		Name:      existingUser.Name,
-		UserName:  existingUser.UserName,
-		Email:     existingUser.Email,
-		Password:  showPassword,
-		CreatedAt: existingUser.CreatedAt,
-		UpdatedAt: existingUser.UpdatedAt,
+		ID:              existingUser.ID,
+		Name:            existingUser.Name,
+		UserName:        existingUser.UserName,
+		Email:           existingUser.Email,
+		TimeZone:        existingUser.TimeZone,
+		EmailVerifiedAt: existingUser.EmailVerifiedAt,
+		CreatedAt:       existingUser.CreatedAt,
+		UpdatedAt:       existingUser.UpdatedAt,
 	}
 
 	// Return the updated user in response
@@ -353,3 +568,188 @@ func UpdateUser(ctx *gin.Context) {
 	})
 
 }
+
+func VerifyEmail(ctx *gin.Context) {
+
+	// Parse the request body
+	var body struct {
+		Token string `json:"token"`
+	}
+	if err := ctx.Bind(&body); err != nil || body.Token == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": "please provide the verification token",
+		})
+		return
+	}
+
+	// Mark the email verified
+	if err := utils.VerifyEmail(body.Token); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, utils.ErrInvalidOneTimeToken) {
+			status = http.StatusBadRequest
+		}
+		ctx.JSON(status, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Email successfully verified",
+	})
+}
+
+func ResendVerification(ctx *gin.Context) {
+
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Send a new verification email, throttled per user
+	if err := utils.ResendEmailVerification(ctx.Request.Context(), user.(models.User)); err != nil {
+		var tooSoon *utils.ResendTooSoonError
+		status := http.StatusInternalServerError
+		switch {
+		case errors.As(err, &tooSoon):
+			status = http.StatusTooManyRequests
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(tooSoon.RetryAfter.Seconds()))))
+		case errors.Is(err, utils.ErrEmailAlreadyVerified):
+			status = http.StatusBadRequest
+		}
+		ctx.JSON(status, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Verification email sent",
+	})
+}
+
+func GetLoginAttempts(ctx *gin.Context) {
+
+	// Extracr user information from context
+	user, exists := ctx.Get("user")
+	if !exists {
+		ctx.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	// Retreive the recent attempts on the account
+	attempts, err := utils.RecentLoginAttempts(user.(models.User))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": "failed to fetch login attempts",
+		})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"attempts": attempts,
+	})
+}
+
+// loginLockedResponse answers a login refused by CheckLoginAllowed.
+func loginLockedResponse(ctx *gin.Context, err error) {
+	var locked *utils.LoginLockedError
+	if !errors.As(err, &locked) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+	ctx.JSON(http.StatusTooManyRequests, gin.H{
+		"success": false,
+		"message": locked.Error(),
+	})
+}
+
+// completeLogin finishes a login once the user has proven who they are,
+// asking for a two-factor code first when the account has it enabled.
+func completeLogin(ctx *gin.Context, user models.User) {
+
+	// Check the account has not been disabled
+	if user.DisabledAt != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": utils.ErrAccountDisabled.Error(),
+		})
+		return
+	}
+
+	// Accounts with two-factor authentication finish logging in with a code
+	if user.TOTPEnabledAt != nil {
+		mfaToken, err := utils.GenerateMFAToken(&user)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"message": err.Error(),
+			})
+			return
+		}
+
+		ctx.JSON(http.StatusOK, gin.H{
+			"success":      true,
+			"message":      "please enter your two-factor code",
+			"mfa_required": true,
+			"mfa_token":    mfaToken,
+		})
+		return
+	}
+
+	finishLogin(ctx, user)
+}
+
+// finishLogin starts a session for the user and sets its cookies.
+func finishLogin(ctx *gin.Context, user models.User) {
+
+	// Start a session and generate its tokens
+	tokens, err := utils.StartSession(ctx, &user)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Set the cookie
+	utils.SendCookie(ctx, tokens.AccessToken, tokens.RefreshToken)
+	utils.RecordLoginAttempt(ctx, user.Email, &user.ID, "")
+
+	message := fmt.Sprintf("Welcome back %v", user.Name)
+
+	// Logging in during the grace period keeps the account
+	cancelled, err := utils.CancelAccountDeletion(&user)
+	if err != nil {
+		log.Printf("Failed to cancel deletion of user %d: %v", user.ID, err)
+	}
+	if cancelled {
+		message += ", your account is no longer scheduled for deletion"
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+	})
+}
@@ -0,0 +1,31 @@
+package initializers
+
+import (
+	"fmt"
+
+	"github.com/Waris-Shaik/todo-backend/models"
+	"gorm.io/gorm"
+)
+
+// PromoteAdmins gives the admin role to the users listed in ADMIN_EMAILS, so
+// a fresh install has someone to manage it. Only verified addresses count,
+// otherwise anyone could sign up with a listed address before its owner.
+func PromoteAdmins() {
+	if err := promoteAdmins(DB); err != nil {
+		fmt.Println("Failed to promote admins:", err)
+	}
+}
+
+// PromoteAdmin gives the admin role to the user if their address is listed
+// in ADMIN_EMAILS and verified, for users who verify it after startup.
+func PromoteAdmin(tx *gorm.DB, userID uint) error {
+	return promoteAdmins(tx.Where("id = ?", userID))
+}
+
+func promoteAdmins(db *gorm.DB) error {
+	emails := Config.Auth.AdminEmails
+	if len(emails) == 0 {
+		return nil
+	}
+	return db.Model(&models.User{}).Where("email IN ? AND email_verified_at IS NOT NULL", emails).Update("role", models.RoleAdmin).Error
+}
@@ -0,0 +1,38 @@
+package initializers
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/Waris-Shaik/todo-backend/migrations"
+)
+
+// CheckDatabaseSchema refuses to start the server while migrations are
+// pending, as the code expects the newest schema. An in-memory database starts
+// empty every time, so it is migrated instead.
+func CheckDatabaseSchema() {
+	if DatabaseDriver() == "memory" {
+		if _, err := migrations.Up(DB); err != nil {
+			log.Fatal("Failed to migrate database: ", err)
+		}
+		return
+	}
+
+	statuses, err := migrations.List(DB)
+	if err != nil {
+		log.Fatal("Failed to read database migrations: ", err)
+	}
+
+	pending := 0
+	for _, status := range statuses {
+		if status.Missing {
+			fmt.Printf("Database has migration %04d_%s applied, which this version does not know about\n", status.Version, status.Name)
+		}
+		if status.AppliedAt == nil {
+			pending++
+		}
+	}
+	if pending > 0 {
+		log.Fatalf("Database schema is %d migration(s) behind, run `migrate up` before starting the server.", pending)
+	}
+}
@@ -3,34 +3,63 @@ package initializers
 import (
 	"fmt"
 	"log"
-	"os"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var DB *gorm.DB
 
+// DatabaseDriver returns the database to connect to: "postgres" (the
+// default), "sqlite" for a local file, or "memory" for a SQLite database that
+// only lives as long as the process.
+func DatabaseDriver() string {
+	return Config.Database.Driver
+}
+
 func ConnectToDB() {
 	var err error
 
-	// Get DB_URL from .env
-	dsn := os.Getenv("DB_URL")
+	// Postgres DSN or SQLite file, checked when the config was loaded
+	dsn := Config.Database.URL
 
-	// DB_URL error
-	if dsn == "" {
-		log.Fatal("DB_URL is not defined in .env file.")
+	var dialector gorm.Dialector
+	switch DatabaseDriver() {
+	case "postgres":
+		dialector = postgres.Open(dsn)
+	case "sqlite":
+		// DB_URL is the path of the database file
+		if dsn == "" {
+			dsn = "todo.db"
+		}
+		dialector = sqlite.Open(dsn)
+	case "memory":
+		// Shared cache so every pooled connection sees the same database
+		dialector = sqlite.Open("file::memory:?cache=shared")
 	}
 
 	// database connection
-	DB, err = gorm.Open(postgres.Open(dsn), &gorm.Config{})
-
-	// DB connection success
-	fmt.Println("Database Connected Successfully..🔥🔥🔥")
+	DB, err = gorm.Open(dialector, &gorm.Config{})
 
 	// DB connection failure
 	if err != nil {
 		log.Fatal("Failed to connect to database..⛔⛔⛔")
 	}
 
+	// DB connection success
+	fmt.Println("Database Connected Successfully..🔥🔥🔥")
+
+}
+
+// CloseDB closes the connection pool once the server has stopped.
+func CloseDB() {
+	sqlDB, err := DB.DB()
+	if err != nil {
+		log.Println("Failed to close database:", err)
+		return
+	}
+	if err := sqlDB.Close(); err != nil {
+		log.Println("Failed to close database:", err)
+	}
 }
@@ -0,0 +1,21 @@
+package initializers
+
+import (
+	"log"
+
+	"github.com/Waris-Shaik/todo-backend/config"
+)
+
+// Config holds the settings of the server. It has the defaults until
+// LoadConfig runs.
+var Config = config.Default()
+
+// LoadConfig reads the configuration and stops the server with every problem
+// it has, so they can all be fixed at once.
+func LoadConfig() {
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
+	}
+	Config = cfg
+}
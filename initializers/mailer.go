@@ -0,0 +1,18 @@
+package initializers
+
+import (
+	"log"
+
+	"github.com/Waris-Shaik/todo-backend/mailers"
+)
+
+// Mailer sends account emails. It logs them until SetupMailer runs.
+var Mailer mailers.Sender = mailers.LogSender{}
+
+func SetupMailer() {
+	sender, err := mailers.FromConfig(Config)
+	if err != nil {
+		log.Fatal("Failed to set up mail sender: ", err)
+	}
+	Mailer = sender
+}
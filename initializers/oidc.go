@@ -0,0 +1,22 @@
+package initializers
+
+import "github.com/Waris-Shaik/todo-backend/oidc"
+
+// OIDC holds the identity providers users can log in with. It is empty until
+// SetupOIDC runs.
+var OIDC = oidc.NewRegistry()
+
+func SetupOIDC() {
+	var configs []oidc.Config
+	for name, provider := range Config.OIDC {
+		configs = append(configs, oidc.Config{
+			Name:         name,
+			Issuer:       provider.Issuer,
+			ClientID:     provider.ClientID,
+			ClientSecret: provider.ClientSecret,
+			RedirectURL:  provider.RedirectURL,
+			Scopes:       provider.Scopes,
+		})
+	}
+	OIDC = oidc.NewRegistry(configs...)
+}
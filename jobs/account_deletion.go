@@ -0,0 +1,32 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/utils"
+)
+
+const accountDeletionInterval = time.Hour
+
+// RunAccountDeletion deletes the accounts whose deletion grace period is
+// over every hour until ctx is cancelled.
+func RunAccountDeletion(ctx context.Context) {
+	ticker := time.NewTicker(accountDeletionInterval)
+	defer ticker.Stop()
+
+	for {
+		if deleted, err := utils.PurgeDeletedAccounts(); err != nil {
+			log.Println("Failed to delete accounts:", err)
+		} else if deleted > 0 {
+			log.Printf("Deleted %d accounts scheduled for deletion", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
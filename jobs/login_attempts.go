@@ -0,0 +1,45 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/utils"
+)
+
+const loginAttemptPurgeInterval = time.Hour
+
+// LoginAttemptRetention returns how long login attempts are kept, set with
+// LOGIN_ATTEMPT_RETENTION (such as "90d").
+func LoginAttemptRetention() time.Duration {
+	return initializers.Config.Retention.LoginAttempts
+}
+
+// RunLoginAttemptPurge deletes login attempts older than retention, and
+// password reset requests that no longer count, every hour until ctx is
+// cancelled.
+func RunLoginAttemptPurge(ctx context.Context, retention time.Duration) {
+	ticker := time.NewTicker(loginAttemptPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := utils.PurgeLoginAttempts(retention); err != nil {
+			log.Println("Failed to purge login attempts:", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d old login attempts", purged)
+		}
+		if purged, err := utils.PurgePasswordResetRequests(); err != nil {
+			log.Println("Failed to purge password reset requests:", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d old password reset requests", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/notifiers"
+)
+
+// ReminderInterval returns how often reminders are checked, set with
+// REMINDER_INTERVAL (such as "30s").
+func ReminderInterval() time.Duration {
+	return initializers.Config.Reminders.Interval
+}
+
+// RunReminders sends the reminders that have come due every interval until
+// ctx is cancelled.
+func RunReminders(ctx context.Context, notifier notifiers.Notifier, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		SendDueReminders(ctx, notifier)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDueReminders notifies the owners of open todos whose reminder time has
+// passed. Each reminder is claimed before it is sent so that it goes out only
+// once even when several servers run the scheduler. Disabled accounts and
+// accounts awaiting deletion get no reminders.
+func SendDueReminders(ctx context.Context, notifier notifiers.Notifier) {
+	now := time.Now()
+
+	// Retreive the reminders that are due
+	var todos []models.Todo
+	result := initializers.DB.
+		Joins("JOIN users ON users.id = todos.user_id").
+		Where("todos.remind_at <= ? AND todos.reminded_at IS NULL AND todos.completed = ?", now, false).
+		Where("users.disabled_at IS NULL AND users.deletion_due_at IS NULL").
+		Find(&todos)
+	if result.Error != nil {
+		log.Println("Failed to fetch due reminders:", result.Error)
+		return
+	}
+
+	for _, todo := range todos {
+		// Claim the reminder, unless the owner was locked out meanwhile
+		claim := initializers.DB.Model(&models.Todo{}).
+			Where("id = ? AND reminded_at IS NULL", todo.ID).
+			Where("user_id IN (SELECT id FROM users WHERE disabled_at IS NULL AND deletion_due_at IS NULL)").
+			Update("reminded_at", now)
+		if claim.Error != nil || claim.RowsAffected == 0 {
+			continue
+		}
+
+		var user models.User
+		if result := initializers.DB.First(&user, todo.UserID); result.Error != nil {
+			log.Printf("Failed to fetch owner of todo %d: %v", todo.ID, result.Error)
+			continue
+		}
+
+		// Release the claim when sending fails so the reminder is retried
+		if err := notifier.Notify(ctx, notifiers.Reminder{Todo: todo, User: user}); err != nil {
+			log.Printf("Failed to send reminder for todo %d: %v", todo.ID, err)
+			initializers.DB.Model(&models.Todo{}).Where("id = ?", todo.ID).Update("reminded_at", nil)
+		}
+	}
+}
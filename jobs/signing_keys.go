@@ -0,0 +1,30 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/utils"
+)
+
+const keyRotationCheckInterval = time.Hour
+
+// RunKeyRotation rotates the token signing keys when they are due, checking
+// every hour until ctx is cancelled.
+func RunKeyRotation(ctx context.Context) {
+	ticker := time.NewTicker(keyRotationCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := utils.RotateSigningKeys(); err != nil {
+			log.Println("Failed to rotate signing keys:", err)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+)
+
+// TrashRetention returns how long deleted todos stay in the trash, set with
+// TRASH_RETENTION (such as "30d" or "72h").
+func TrashRetention() time.Duration {
+	return initializers.Config.Retention.Trash
+}
+
+// TrashPurgeInterval returns how often the trash is emptied, set with
+// TRASH_PURGE_INTERVAL.
+func TrashPurgeInterval() time.Duration {
+	return initializers.Config.Retention.TrashPurgeInterval
+}
+
+// RunTrashPurge permanently deletes todos that have been in the trash longer
+// than retention, every interval until ctx is cancelled.
+func RunTrashPurge(ctx context.Context, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if purged, err := PurgeTrash(retention); err != nil {
+			log.Println("Failed to purge trash:", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d todos from the trash", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeTrash permanently deletes the todos trashed more than retention ago,
+// with their subtasks, and returns how many trashed todos were deleted.
+func PurgeTrash(retention time.Duration) (int, error) {
+	var ids []uint
+	result := initializers.DB.Unscoped().Model(&models.Todo{}).
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", time.Now().Add(-retention)).
+		Pluck("id", &ids)
+	if result.Error != nil || len(ids) == 0 {
+		return 0, result.Error
+	}
+
+	// Subtasks go along with their parent even if trashed more recently
+	todos := repositories.New(initializers.DB).Todos
+	for purged, id := range ids {
+		if err := todos.Purge(id); err != nil {
+			return purged, err
+		}
+	}
+	return len(ids), nil
+}
@@ -0,0 +1,33 @@
+package mailers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileSender writes every email to its own .eml file in Dir, so development
+// mail can be opened with a mail client.
+type FileSender struct {
+	Dir  string
+	From string
+}
+
+// NewFileSender writes to dir, creating it when missing.
+func NewFileSender(dir string, from string) (*FileSender, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	if from == "" {
+		from = "todo@localhost"
+	}
+	return &FileSender{Dir: dir, From: from}, nil
+}
+
+func (sender *FileSender) Send(ctx context.Context, message Message) error {
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), filepath.Base(message.To))
+	return os.WriteFile(filepath.Join(sender.Dir, name), message.Bytes(sender.From), 0o600)
+}
@@ -0,0 +1,15 @@
+package mailers
+
+import (
+	"context"
+	"log"
+)
+
+// LogSender writes emails to the server log. It is meant for local
+// development.
+type LogSender struct{}
+
+func (LogSender) Send(ctx context.Context, message Message) error {
+	log.Printf("Mail to <%s>: %s\n%s", message.To, message.Subject, message.Body)
+	return nil
+}
@@ -0,0 +1,53 @@
+package mailers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/config"
+)
+
+// Message is a plain text email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Sender delivers account emails such as password resets.
+type Sender interface {
+	Send(ctx context.Context, message Message) error
+}
+
+// FromConfig builds the sender selected by mail.sender, "log" (the default),
+// "file" or "smtp".
+func FromConfig(cfg *config.Config) (Sender, error) {
+	switch kind := cfg.Mail.Sender; kind {
+	case "log":
+		return LogSender{}, nil
+	case "file":
+		return NewFileSender(cfg.Mail.FileDir, cfg.SMTP.From)
+	case "smtp":
+		return NewSMTPSender(cfg.SMTP), nil
+	default:
+		return nil, fmt.Errorf("unknown mail sender %q", kind)
+	}
+}
+
+// headerLine keeps line breaks in a subject from starting new headers.
+var headerLine = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ")
+
+// Bytes renders the message with its headers, ready to hand to an SMTP
+// server.
+func (message Message) Bytes(from string) []byte {
+	var raw strings.Builder
+	fmt.Fprintf(&raw, "From: %s\r\n", from)
+	fmt.Fprintf(&raw, "To: %s\r\n", message.To)
+	fmt.Fprintf(&raw, "Subject: %s\r\n", headerLine.Replace(message.Subject))
+	fmt.Fprintf(&raw, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	raw.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	raw.WriteString(strings.ReplaceAll(strings.ReplaceAll(message.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return []byte(raw.String())
+}
@@ -0,0 +1,85 @@
+package mailers
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/smtp"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/config"
+)
+
+// SMTPSender sends emails through an SMTP server.
+type SMTPSender struct {
+	Addr     string // host:port of the SMTP server
+	From     string
+	Username string // Leave empty for servers that do not require auth
+	Password string
+}
+
+// NewSMTPSender sends through the configured SMTP server.
+func NewSMTPSender(settings config.SMTP) *SMTPSender {
+	return &SMTPSender{
+		Addr:     net.JoinHostPort(settings.Host, settings.Port),
+		From:     settings.From,
+		Username: settings.Username,
+		Password: settings.Password,
+	}
+}
+
+// Send delivers the message, upgrading to TLS when the server offers it. The
+// whole exchange is bounded by ctx.
+func (sender *SMTPSender) Send(ctx context.Context, message Message) error {
+	host, _, err := net.SplitHostPort(sender.Addr)
+	if err != nil {
+		return err
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", sender.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+	if sender.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", sender.Username, sender.Password, host)); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(sender.From); err != nil {
+		return err
+	}
+	if err := client.Rcpt(message.To); err != nil {
+		return err
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := writer.Write(message.Bytes(sender.From)); err != nil {
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
@@ -0,0 +1,126 @@
+package mailers
+
+import (
+	"context"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeSMTP accepts one connection on a local port and records what it is
+// sent. It speaks just enough SMTP for net/smtp.
+type fakeSMTP struct {
+	listener   net.Listener
+	recipients []string
+	data       string
+	done       chan struct{}
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	server := &fakeSMTP{listener: listener, done: make(chan struct{})}
+	go server.serve()
+	return server
+}
+
+func (server *fakeSMTP) serve() {
+	defer close(server.done)
+	conn, err := server.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 localhost ESMTP")
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		switch verb {
+		case "EHLO", "HELO":
+			text.PrintfLine("250 localhost")
+		case "RCPT":
+			server.recipients = append(server.recipients, strings.TrimPrefix(line, "RCPT TO:"))
+			text.PrintfLine("250 OK")
+		case "DATA":
+			text.PrintfLine("354 Go ahead")
+			lines, err := text.ReadDotLines()
+			if err != nil {
+				return
+			}
+			server.data = strings.Join(lines, "\n")
+			text.PrintfLine("250 OK")
+		case "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("250 OK")
+		}
+	}
+}
+
+func TestSMTPSender(t *testing.T) {
+	server := newFakeSMTP(t)
+	sender := &SMTPSender{Addr: server.listener.Addr().String(), From: "todo@example.com"}
+
+	err := sender.Send(context.Background(), Message{
+		To:      "alice@example.com",
+		Subject: "Hello\r\nBcc: mallory@example.com",
+		Body:    "First line\nSecond line",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	<-server.done
+
+	if strings.Join(server.recipients, ",") != "<alice@example.com>" {
+		t.Errorf("recipients = %v, want only alice", server.recipients)
+	}
+	for _, want := range []string{"From: todo@example.com\n", "To: alice@example.com\n", "Subject: Hello Bcc: mallory@example.com\n", "\n\nFirst line\nSecond line"} {
+		if !strings.Contains(server.data, want) {
+			t.Errorf("message has no %q:\n%s", want, server.data)
+		}
+	}
+	if strings.Contains(server.data, "\nBcc:") {
+		t.Errorf("subject started a new header:\n%s", server.data)
+	}
+}
+
+func TestSMTPSenderStopsWithTheContext(t *testing.T) {
+	// A server that accepts but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(5 * time.Second)
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	sender := &SMTPSender{Addr: listener.Addr().String(), From: "todo@example.com"}
+
+	start := time.Now()
+	if err := sender.Send(ctx, Message{To: "alice@example.com", Subject: "Hello"}); err == nil {
+		t.Fatal("sent to a server that never answered")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("send took %s after its context ended", elapsed)
+	}
+}
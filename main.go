@@ -1,55 +1,140 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
-	"github.com/Waris-Shaik/todo-backend/controllers"
 	"github.com/Waris-Shaik/todo-backend/initializers"
-	"github.com/Waris-Shaik/todo-backend/middlewares"
-	"github.com/gin-gonic/gin"
-)
+	"github.com/Waris-Shaik/todo-backend/jobs"
+	"github.com/Waris-Shaik/todo-backend/notifiers"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+	"github.com/Waris-Shaik/todo-backend/routes"
+	"github.com/Waris-Shaik/todo-backend/utils"
 
-func init() {
-	initializers.LoadEnvVariables()
-	initializers.ConnectToDB()
-	initializers.SyncDatabase()
-}
+	// Embed the time zone database for containers that do not ship one
+	_ "time/tzdata"
+)
 
 func main() {
 
+	// Schema migrations, see migrate.go
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(os.Args[2:])
+		return
+	}
+
+	// Configuration, see config_command.go
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		configCommand(os.Args[2:])
+		return
+	}
+
+	// Environment, database and integrations
+	initializers.LoadConfig()
+	initializers.ConnectToDB()
+	initializers.CheckDatabaseSchema()
+	initializers.PromoteAdmins()
+	initializers.SetupMailer()
+	initializers.SetupOIDC()
+
 	// PORT
-	PORT := os.Getenv("PORT")
+	PORT := initializers.Config.Server.Port
+
+	// Stopped by SIGINT or SIGTERM, which lets the server drain
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	var background sync.WaitGroup
+	runJob := func(job func(ctx context.Context)) {
+		background.Add(1)
+		go func() {
+			defer background.Done()
+			job(ctx)
+		}()
+	}
+
+	// Token signing keys, created on first start and rotated from then on
+	if err := utils.RotateSigningKeys(); err != nil {
+		log.Fatal("Failed to set up signing keys: ", err)
+	}
+	runJob(jobs.RunKeyRotation)
 
-	// PORT error
-	if PORT == "" {
-		log.Fatal("Error PORT is not defined in .env File")
+	// Reminder scheduler
+	notifier, err := notifiers.FromConfig(initializers.Config)
+	if err != nil {
+		log.Fatal("Failed to set up reminders: ", err)
 	}
+	runJob(func(ctx context.Context) { jobs.RunReminders(ctx, notifier, jobs.ReminderInterval()) })
+
+	// Trash retention
+	runJob(func(ctx context.Context) { jobs.RunTrashPurge(ctx, jobs.TrashRetention(), jobs.TrashPurgeInterval()) })
+
+	// Login attempt retention
+	runJob(func(ctx context.Context) { jobs.RunLoginAttemptPurge(ctx, jobs.LoginAttemptRetention()) })
+
+	// Accounts past their deletion grace period
+	runJob(jobs.RunAccountDeletion)
 
 	// router
-	router := gin.Default()
-
-	// routes
-	router.POST("/api/v1/users/signup", controllers.SignUp)
-	router.POST("/api/v1/users/login", controllers.Login)
-	router.GET("/api/v1/users/logout", controllers.Logout)
-	router.GET("/api/v1/users/me", middlewares.IsAuthenticated, controllers.Me)
-	router.GET("/api/v1/users/all", middlewares.IsAuthenticated, controllers.GetUsers)
-	router.PATCH("/api/v1/users/updatemyprofile", middlewares.IsAuthenticated, controllers.UpdateUser)
-	router.POST("/api/v1/todos/new", middlewares.IsAuthenticated, controllers.CreateTodo)
-	router.GET("/api/v1/todos/my", middlewares.IsAuthenticated, controllers.GetTodos)
-	router.GET("/api/v1/todos/:id", middlewares.IsAuthenticated, controllers.GetSingleTodo)
-	router.PATCH("/api/v1/todos/:id", middlewares.IsAuthenticated, controllers.UpdateTodo)
-	router.PUT("/api/v1/todos/:id", middlewares.IsAuthenticated, controllers.EditTodo)
-	router.DELETE("/api/v1/todos/:id", middlewares.IsAuthenticated, controllers.DeleteTodo)
+	router := routes.SetupRouter(repositories.New(initializers.DB))
+
+	// Server with timeouts, so slow clients cannot hold connections forever
+	settings := initializers.Config.Server
+	server := &http.Server{
+		Addr:              ":" + PORT,
+		Handler:           router,
+		ReadHeaderTimeout: settings.ReadHeaderTimeout,
+		ReadTimeout:       settings.ReadTimeout,
+		WriteTimeout:      settings.WriteTimeout,
+		IdleTimeout:       settings.IdleTimeout,
+	}
+
+	// Server error
+	listener, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		log.Fatal("Failed to connect to server: ", err)
+	}
 
 	// Server listening
 	fmt.Println("Server is listening on PORT:", PORT, "⚡⚡⚡")
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Server stopped: ", err)
+		}
+	}()
 
-	// Server error
-	if err := router.Run(":" + PORT); err != nil {
-		log.Fatal("Failed to connect to server")
+	// A second signal stops the process without waiting
+	<-ctx.Done()
+	stop()
+	fmt.Println("Shutting down, finishing requests in flight..")
+
+	// Requests in flight and background jobs get the shutdown timeout to finish
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), settings.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Println("Failed to finish requests in flight:", err)
 	}
 
+	jobsDone := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(jobsDone)
+	}()
+	select {
+	case <-jobsDone:
+	case <-shutdownCtx.Done():
+		log.Println("Background jobs did not stop in time")
+	}
+
+	// Database pool
+	initializers.CloseDB()
+	fmt.Println("Server stopped")
+
 }
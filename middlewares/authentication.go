@@ -1,21 +1,20 @@
 package middlewares
 
 import (
-	"fmt"
 	"net/http"
-	"os"
+	"strings"
 
-	"github.com/Waris-Shaik/todo-backend/initializers"
 	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+	"github.com/Waris-Shaik/todo-backend/utils"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
 func IsAuthenticated(ctx *gin.Context) {
 
-	// Get the JWT token from cookies
-	tokenString, err := ctx.Cookie("token")
-	if err != nil {
+	// Get the token from the Authorization header or cookies
+	tokenString := utils.RequestToken(ctx)
+	if tokenString == "" {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 			"success": false,
 			"message": "please login",
@@ -23,21 +22,17 @@ func IsAuthenticated(ctx *gin.Context) {
 		return
 	}
 
+	// Personal access tokens are looked up rather than parsed
+	if strings.HasPrefix(tokenString, models.PersonalAccessTokenPrefix) {
+		authenticateAccessToken(ctx, tokenString)
+		return
+	}
+
 	// fmt.Println("Token String is:", tokenString)
 
 	// Parse and validate JWT token
-
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-
-		// Ensure the signing method is valid
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["Authorization"])
-		}
-
-		return []byte(os.Getenv("JWT_SECRET_KEY")), nil
-	})
-
-	if err != nil || !token.Valid {
+	claims, err := utils.ParseToken(tokenString, true)
+	if err != nil {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 			"success": false,
 			"message": "invalid token",
@@ -46,28 +41,36 @@ func IsAuthenticated(ctx *gin.Context) {
 	}
 
 	// Extract user ID from claims and fetch it from the user data
-	claims, ok := token.Claims.(jwt.MapClaims)
+	userID, ok := utils.ClaimID(claims, "_id")
 	if !ok {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"message": "invalid token claims",
+			"message": "invalid user id in token",
 		})
 		return
 	}
 
-	userID, ok := claims["_id"]
+	// Reject tokens whose session has been revoked
+	sessionID, ok := utils.ClaimID(claims, "sid")
 	if !ok {
 		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
 			"success": false,
-			"message": "invalid user id in token",
+			"message": "session expired please login",
+		})
+		return
+	}
+	session, ok := utils.ActiveSession(sessionID, userID)
+	if !ok {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "session expired please login",
 		})
 		return
 	}
 
 	// Retreive user from the database
-	var user models.User
-	result := initializers.DB.First(&user, userID)
-	if result.Error != nil {
+	user, err := repositories.FromContext(ctx).Users.FindByID(userID)
+	if err != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{
 			"success": false,
 			"message": "user not found",
@@ -75,10 +78,98 @@ func IsAuthenticated(ctx *gin.Context) {
 		return
 	}
 
+	// Disabled accounts are locked out even with a live session
+	if user.DisabledAt != nil {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": utils.ErrAccountDisabled.Error(),
+		})
+		return
+	}
+
 	// Attach the user information to the request context
 	ctx.Set("user", user)
+	ctx.Set("session_id", sessionID)
+	utils.TouchSession(ctx, session)
 
 	// Proceed to the next middleware or route handler
 	ctx.Next()
 
 }
+
+func authenticateAccessToken(ctx *gin.Context, tokenString string) {
+
+	token, err := utils.FindPersonalAccessToken(tokenString)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": err.Error(),
+		})
+		return
+	}
+
+	// Retreive the token's owner from the database
+	user, err := repositories.FromContext(ctx).Users.FindByID(token.UserID)
+	if err != nil {
+		ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"message": "user not found",
+		})
+		return
+	}
+
+	if user.DisabledAt != nil {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": utils.ErrAccountDisabled.Error(),
+		})
+		return
+	}
+
+	// Tokens stay unusable until the user logs in to cancel the deletion
+	if user.DeletionDueAt != nil {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": utils.ErrAccountPendingDeletion.Error(),
+		})
+		return
+	}
+
+	// Attach the user and the token's scopes to the request context
+	ctx.Set("user", user)
+	ctx.Set("scopes", token.Scopes)
+
+	ctx.Next()
+}
+
+// RequireScope limits a route to personal access tokens granted scope.
+// Login sessions carry no scopes and have full access.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		scopes, ok := ctx.Get("scopes")
+		if ok && !utils.HasScope(scopes.([]string), scope) {
+			ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"message": "token is missing the " + scope + " scope",
+			})
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
+// RequireSession limits a route to login sessions. Personal access tokens
+// are refused whatever their scopes, so a leaked token cannot be used to
+// take over the account's credentials.
+func RequireSession(ctx *gin.Context) {
+	if _, ok := ctx.Get("scopes"); ok {
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "please login, access tokens cannot be used here",
+		})
+		return
+	}
+
+	ctx.Next()
+}
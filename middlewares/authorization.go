@@ -0,0 +1,36 @@
+package middlewares
+
+import (
+	"net/http"
+
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/gin-gonic/gin"
+)
+
+// RequireRole limits a route to users with one of the given roles. It runs
+// after IsAuthenticated.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, exists := ctx.Get("user")
+		if !exists {
+			ctx.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"message": "please login",
+			})
+			return
+		}
+
+		role := user.(models.User).Role
+		for _, allowed := range roles {
+			if role == allowed {
+				ctx.Next()
+				return
+			}
+		}
+
+		ctx.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"message": "you are not allowed to do this",
+		})
+	}
+}
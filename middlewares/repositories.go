@@ -0,0 +1,15 @@
+package middlewares
+
+import (
+	"github.com/Waris-Shaik/todo-backend/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+// UseRepositories attaches the repositories handlers read and write users
+// and todos through, see repositories.FromContext.
+func UseRepositories(repos repositories.Repositories) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		ctx.Set("repositories", repos)
+		ctx.Next()
+	}
+}
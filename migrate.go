@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/migrations"
+)
+
+const migrateUsage = `Usage: migrate <command>
+
+Commands:
+  up           apply every pending migration
+  down [N]     roll back the last N applied migrations (default 1)
+  status       list the migrations and when they were applied
+  create NAME  write empty up and down files for a new migration under ./migrations`
+
+// migrate runs the migrate subcommand with its arguments.
+func migrate(args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+
+	// Creating files does not need a database
+	if args[0] == "create" {
+		if len(args) != 2 {
+			log.Fatal(migrateUsage)
+		}
+		paths, err := migrations.Create("migrations", args[1])
+		if err != nil {
+			log.Fatal("Failed to create migration: ", err)
+		}
+		for _, path := range paths {
+			fmt.Println("Created", path)
+		}
+		return
+	}
+
+	initializers.LoadConfig()
+	initializers.ConnectToDB()
+
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(initializers.DB)
+		for _, migration := range applied {
+			fmt.Printf("Applied %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatal("Failed to migrate database: ", err)
+		}
+		if len(applied) == 0 {
+			fmt.Println("Database is up to date")
+		}
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			var err error
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				log.Fatal("Number of migrations to roll back must be a positive number")
+			}
+		}
+		rolledBack, err := migrations.Down(initializers.DB, steps)
+		for _, migration := range rolledBack {
+			fmt.Printf("Rolled back %04d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			log.Fatal("Failed to roll back database: ", err)
+		}
+		if len(rolledBack) == 0 {
+			fmt.Println("No migrations to roll back")
+		}
+
+	case "status":
+		statuses, err := migrations.List(initializers.DB)
+		if err != nil {
+			log.Fatal("Failed to read database migrations: ", err)
+		}
+		for _, status := range statuses {
+			state := "pending"
+			if status.AppliedAt != nil {
+				state = "applied " + status.AppliedAt.Local().Format("2006-01-02 15:04:05")
+			}
+			if status.Missing {
+				state += " (not in this version)"
+			}
+			fmt.Printf("%04d_%-40s %s\n", status.Version, status.Name, state)
+		}
+
+	default:
+		log.Fatal(migrateUsage)
+	}
+}
@@ -0,0 +1,333 @@
+// Package migrations keeps the database schema in numbered SQL migrations,
+// embedded in the binary, and records the applied ones in the
+// schema_migrations table.
+//
+// Each migration is a pair of files per dialect, for example
+// postgres/0002_add_todo_notes.up.sql and postgres/0002_add_todo_notes.down.sql,
+// with a matching pair under sqlite. Statements are separated by semicolons,
+// so a migration cannot use a semicolon inside a statement.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+//go:embed postgres/*.sql sqlite/*.sql
+var embedded embed.FS
+
+// files holds the migrations Load reads, the embedded ones outside tests.
+var files fs.FS = embedded
+
+// Dialects the migrations are written for, named like GORM's dialectors.
+var Dialects = []string{"postgres", "sqlite"}
+
+var migrationFile = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// Migration is one numbered change to the schema.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration along with when it was applied, nil while pending.
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+	Missing   bool // Applied to the database but unknown to this binary
+}
+
+// appliedMigration is a row of the schema_migrations table.
+type appliedMigration struct {
+	Version   uint `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (appliedMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Dialect returns the dialect of the database's migrations.
+func Dialect(db *gorm.DB) (string, error) {
+	name := db.Dialector.Name()
+	for _, dialect := range Dialects {
+		if name == dialect {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("no migrations for %s databases", name)
+}
+
+// Load returns the migrations embedded for a dialect, oldest first.
+func Load(dialect string) ([]Migration, error) {
+	return read(files, dialect)
+}
+
+func read(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[uint]*Migration{}
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+		version, err := strconv.ParseUint(match[1], 10, 32)
+		if err != nil || version == 0 {
+			return nil, fmt.Errorf("migration %s: invalid version", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, dir+"/"+entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[uint(version)]
+		if !ok {
+			migration = &Migration{Version: uint(version), Name: match[2]}
+			byVersion[uint(version)] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, migration.Name, match[2])
+		}
+		if match[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if strings.TrimSpace(migration.Up) == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// ensureTable creates the schema_migrations table.
+func ensureTable(db *gorm.DB, dialect string) error {
+	timestamp := "timestamptz"
+	if dialect == "sqlite" {
+		timestamp = "datetime"
+	}
+	return db.Exec("CREATE TABLE IF NOT EXISTS schema_migrations (version bigint PRIMARY KEY, name text NOT NULL, applied_at " + timestamp + " NOT NULL)").Error
+}
+
+// List returns every migration with whether it was applied, oldest first.
+func List(db *gorm.DB) ([]Status, error) {
+	dialect, err := Dialect(db)
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := Load(dialect)
+	if err != nil {
+		return nil, err
+	}
+	if err := ensureTable(db, dialect); err != nil {
+		return nil, err
+	}
+
+	var applied []appliedMigration
+	if result := db.Order("version ASC").Find(&applied); result.Error != nil {
+		return nil, result.Error
+	}
+	appliedAt := make(map[uint]time.Time, len(applied))
+	for _, migration := range applied {
+		appliedAt[migration.Version] = migration.AppliedAt
+	}
+
+	statuses := make([]Status, 0, len(migrations))
+	known := make(map[uint]bool, len(migrations))
+	for _, migration := range migrations {
+		known[migration.Version] = true
+		status := Status{Migration: migration}
+		if at, ok := appliedAt[migration.Version]; ok {
+			status.AppliedAt = &at
+		}
+		statuses = append(statuses, status)
+	}
+
+	// A newer binary may have applied migrations this one does not have
+	for _, migration := range applied {
+		if !known[migration.Version] {
+			at := migration.AppliedAt
+			statuses = append(statuses, Status{
+				Migration: Migration{Version: migration.Version, Name: migration.Name},
+				AppliedAt: &at,
+				Missing:   true,
+			})
+		}
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, nil
+}
+
+// Pending returns the migrations that still have to be applied, oldest first.
+func Pending(db *gorm.DB) ([]Migration, error) {
+	statuses, err := List(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, status := range statuses {
+		if status.AppliedAt == nil {
+			pending = append(pending, status.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Current returns the newest version applied to the database, 0 when none
+// is. Unlike List it only reads, and fails when schema_migrations is missing.
+func Current(db *gorm.DB) (uint, error) {
+	var version sql.NullInt64
+	if err := db.Raw("SELECT MAX(version) FROM schema_migrations").Row().Scan(&version); err != nil {
+		return 0, err
+	}
+	return uint(version.Int64), nil
+}
+
+// Up applies the pending migrations, each in its own transaction, and returns
+// the ones it applied.
+func Up(db *gorm.DB) ([]Migration, error) {
+	pending, err := Pending(db)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, migration := range pending {
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := execute(tx, migration.Up); err != nil {
+				return err
+			}
+			return tx.Create(&appliedMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now().UTC()}).Error
+		})
+		if err != nil {
+			return pending[:i], fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+	}
+	return pending, nil
+}
+
+// Down rolls back the last steps applied migrations, newest first, and
+// returns the ones it rolled back.
+func Down(db *gorm.DB, steps int) ([]Migration, error) {
+	statuses, err := List(db)
+	if err != nil {
+		return nil, err
+	}
+
+	var rolledBack []Migration
+	for i := len(statuses) - 1; i >= 0 && len(rolledBack) < steps; i-- {
+		migration := statuses[i]
+		if migration.AppliedAt == nil {
+			continue
+		}
+		if migration.Missing {
+			return rolledBack, fmt.Errorf("migration %d_%s is not in this binary, roll it back with the version that applied it", migration.Version, migration.Name)
+		}
+		// A missing or comment-only down file would forget the migration
+		// without undoing it
+		if len(statements(migration.Down)) == 0 {
+			return rolledBack, fmt.Errorf("migration %d_%s has nothing in its down file to roll it back with", migration.Version, migration.Name)
+		}
+
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := execute(tx, migration.Down); err != nil {
+				return err
+			}
+			return tx.Delete(&appliedMigration{}, "version = ?", migration.Version).Error
+		})
+		if err != nil {
+			return rolledBack, fmt.Errorf("migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+		rolledBack = append(rolledBack, migration.Migration)
+	}
+	return rolledBack, nil
+}
+
+// execute runs the statements of a migration file.
+func execute(tx *gorm.DB, sql string) error {
+	for _, statement := range statements(sql) {
+		if err := tx.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statements splits a migration file into its statements, leaving out
+// comments.
+func statements(sql string) []string {
+	var lines []string
+	for _, line := range strings.Split(sql, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			lines = append(lines, line)
+		}
+	}
+
+	var statements []string
+	for _, statement := range strings.Split(strings.Join(lines, "\n"), ";") {
+		if statement = strings.TrimSpace(statement); statement != "" {
+			statements = append(statements, statement)
+		}
+	}
+	return statements
+}
+
+// Create writes empty up and down files for a new migration under dir, the
+// migrations source directory, for every dialect. It returns the paths of
+// the new files; they are embedded the next time the binary is built.
+func Create(dir string, name string) ([]string, error) {
+	name = strings.Trim(regexp.MustCompile(`[^a-z0-9]+`).ReplaceAllString(strings.ToLower(name), "_"), "_")
+	if name == "" {
+		return nil, fmt.Errorf("please give the migration a name")
+	}
+
+	// The new migration follows the newest one of any dialect
+	var version uint
+	for _, dialect := range Dialects {
+		migrations, err := read(os.DirFS(dir), dialect)
+		if err != nil {
+			return nil, err
+		}
+		if len(migrations) > 0 {
+			version = max(version, migrations[len(migrations)-1].Version)
+		}
+	}
+	version++
+
+	var paths []string
+	for _, dialect := range Dialects {
+		for _, direction := range []string{"up", "down"} {
+			path := filepath.Join(dir, dialect, fmt.Sprintf("%04d_%s.%s.sql", version, name, direction))
+			content := fmt.Sprintf("-- %s migration %04d_%s for %s\n", strings.ToUpper(direction[:1])+direction[1:], version, name, dialect)
+			if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+				return paths, err
+			}
+			paths = append(paths, path)
+		}
+	}
+	return paths, nil
+}
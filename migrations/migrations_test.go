@@ -0,0 +1,159 @@
+package migrations
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	return db
+}
+
+func TestUpAndDown(t *testing.T) {
+	db := openTestDB(t)
+
+	migrations, err := Load("sqlite")
+	if err != nil || len(migrations) == 0 {
+		t.Fatalf("Load = %v, %v, want the sqlite migrations", migrations, err)
+	}
+	if _, err := Current(db); err == nil || db.Migrator().HasTable("schema_migrations") {
+		t.Fatal("Current created schema_migrations or read a version without it")
+	}
+
+	applied, err := Up(db)
+	if err != nil || len(applied) != len(migrations) {
+		t.Fatalf("Up applied %d migrations, %v, want %d", len(applied), err, len(migrations))
+	}
+	if pending, err := Pending(db); err != nil || len(pending) != 0 {
+		t.Fatalf("Pending after Up = %v, %v, want none", pending, err)
+	}
+	if applied, err := Up(db); err != nil || len(applied) != 0 {
+		t.Fatalf("second Up applied %v, %v, want nothing", applied, err)
+	}
+	if current, err := Current(db); err != nil || current != migrations[len(migrations)-1].Version {
+		t.Fatalf("Current after Up = %d, %v, want %d", current, err, migrations[len(migrations)-1].Version)
+	}
+
+	rolledBack, err := Down(db, len(migrations))
+	if err != nil || len(rolledBack) != len(migrations) {
+		t.Fatalf("Down rolled back %d migrations, %v, want %d", len(rolledBack), err, len(migrations))
+	}
+	if db.Migrator().HasTable("users") {
+		t.Fatal("users table is left after rolling everything back")
+	}
+	if pending, _ := Pending(db); len(pending) != len(migrations) {
+		t.Fatalf("%d pending after Down, want %d", len(pending), len(migrations))
+	}
+	if current, err := Current(db); err != nil || current != 0 {
+		t.Fatalf("Current after Down = %d, %v, want 0", current, err)
+	}
+}
+
+func TestUnknownAppliedMigration(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := Up(db); err != nil {
+		t.Fatal(err)
+	}
+	db.Create(&appliedMigration{Version: 9999, Name: "from_the_future"})
+
+	statuses, err := List(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := statuses[len(statuses)-1]; last.Version != 9999 || !last.Missing {
+		t.Fatalf("last status = %+v, want 9999 marked missing", last)
+	}
+	if _, err := Down(db, 1); err == nil {
+		t.Fatal("rolled back a migration this binary does not have")
+	}
+}
+
+func TestDownWithoutStatements(t *testing.T) {
+	previous := files
+	files = fstest.MapFS{
+		"sqlite/0001_create_things.up.sql":   {Data: []byte("CREATE TABLE things (id integer);")},
+		"sqlite/0002_add_colors.up.sql":      {Data: []byte("ALTER TABLE things ADD COLUMN color text;")},
+		"sqlite/0002_add_colors.down.sql":    {Data: []byte("-- Down migration 0002_add_colors for sqlite\n")},
+		"postgres/0001_create_things.up.sql": {Data: []byte("CREATE TABLE things (id integer);")},
+	}
+	t.Cleanup(func() { files = previous })
+
+	db := openTestDB(t)
+	if _, err := Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither a comment-only nor a missing down file rolls anything back, and
+	// both migrations stay applied
+	if rolledBack, err := Down(db, 1); err == nil || len(rolledBack) != 0 {
+		t.Fatalf("Down with a comment-only file = %v, %v, want an error", rolledBack, err)
+	}
+	db.Exec("DELETE FROM schema_migrations WHERE version = 2")
+	if rolledBack, err := Down(db, 1); err == nil || len(rolledBack) != 0 {
+		t.Fatalf("Down without a down file = %v, %v, want an error", rolledBack, err)
+	}
+	if pending, err := Pending(db); err != nil || len(pending) != 1 || pending[0].Version != 2 {
+		t.Fatalf("Pending = %v, %v, want only 0002", pending, err)
+	}
+}
+
+// The migrations have to keep up with the models
+func TestMigrationsMatchModels(t *testing.T) {
+	db := openTestDB(t)
+	if _, err := Up(db); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, model := range []interface{}{&models.User{}, &models.Todo{}, &models.TodoShare{}, &models.Tag{}, &models.Project{}, &models.Session{}, &models.RefreshToken{}, &models.PersonalAccessToken{}, &models.OneTimeToken{}, &models.RecoveryCode{}, &models.LoginAttempt{}, models.LoginAttempt{}, &models.PasswordResetRequest{}, &models.UserIdentity{}, &models.OIDCLoginState{}, &models.SigningKey{}} {
+		statement := &gorm.Statement{DB: db}
+		if err := statement.Parse(model); err != nil {
+			t.Fatal(err)
+		}
+		if !db.Migrator().HasTable(model) {
+			t.Errorf("no table %s for %T", statement.Schema.Table, model)
+			continue
+		}
+		for _, field := range statement.Schema.Fields {
+			if field.DBName == "" || field.IgnoreMigration {
+				continue
+			}
+			if !db.Migrator().HasColumn(model, field.DBName) {
+				t.Errorf("table %s has no column %s", statement.Schema.Table, field.DBName)
+			}
+		}
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir := t.TempDir()
+	for _, dialect := range Dialects {
+		os.Mkdir(filepath.Join(dir, dialect), 0o755)
+	}
+	os.WriteFile(filepath.Join(dir, "sqlite", "0003_add_notes.up.sql"), []byte("SELECT 1;"), 0o644)
+
+	paths, err := Create(dir, "Add todo colors!")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 2*len(Dialects) {
+		t.Fatalf("created %v, want an up and down file per dialect", paths)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "postgres", "0004_add_todo_colors.down.sql")); err != nil {
+		t.Fatalf("down file for postgres: %v", err)
+	}
+	if _, err := Create(dir, "  "); err == nil {
+		t.Fatal("created a migration without a name")
+	}
+}
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS password_reset_requests (
+	id bigserial PRIMARY KEY,
+	email text NOT NULL,
+	ip text,
+	created_at timestamptz
+);
+CREATE INDEX IF NOT EXISTS idx_password_reset_requests_email ON password_reset_requests (email);
+CREATE INDEX IF NOT EXISTS idx_password_reset_requests_ip ON password_reset_requests (ip);
+CREATE INDEX IF NOT EXISTS idx_password_reset_requests_created_at ON password_reset_requests (created_at);
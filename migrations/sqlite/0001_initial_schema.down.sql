@@ -0,0 +1,16 @@
+DROP TABLE IF EXISTS signing_keys;
+DROP TABLE IF EXISTS o_id_c_login_states;
+DROP TABLE IF EXISTS user_identities;
+DROP TABLE IF EXISTS login_attempts;
+DROP TABLE IF EXISTS recovery_codes;
+DROP TABLE IF EXISTS one_time_tokens;
+DROP TABLE IF EXISTS personal_access_tokens;
+DROP TABLE IF EXISTS refresh_tokens;
+DROP TABLE IF EXISTS sessions;
+DROP TABLE IF EXISTS projects;
+DROP TABLE IF EXISTS todo_shares;
+DROP TABLE IF EXISTS todo_tags;
+DROP TABLE IF EXISTS tags;
+DROP TABLE IF EXISTS todos;
+DROP TABLE IF EXISTS user_lites;
+DROP TABLE IF EXISTS users;
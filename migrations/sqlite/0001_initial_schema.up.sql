@@ -0,0 +1,221 @@
+-- Schema as created by GORM's AutoMigrate before versioned migrations. Every
+-- statement is conditional, so databases created back then are adopted as is.
+
+CREATE TABLE IF NOT EXISTS users (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	name text,
+	user_name text,
+	email text,
+	password text,
+	time_zone text DEFAULT 'UTC',
+	role text NOT NULL DEFAULT 'user',
+	disabled_at datetime,
+	email_verified_at datetime,
+	totp_secret text,
+	totp_enabled_at datetime,
+	totp_last_step integer,
+	deletion_due_at datetime,
+	created_at datetime DEFAULT CURRENT_TIMESTAMP,
+	updated_at datetime DEFAULT NULL,
+	CONSTRAINT uni_users_email UNIQUE (email)
+);
+CREATE INDEX IF NOT EXISTS idx_users_role ON users (role);
+CREATE INDEX IF NOT EXISTS idx_users_deletion_due_at ON users (deletion_due_at);
+
+-- Owners shown on todos, filled in when a todo is created
+CREATE TABLE IF NOT EXISTS user_lites (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	user_name text,
+	email text
+);
+
+CREATE TABLE IF NOT EXISTS todos (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime,
+	title text NOT NULL,
+	description text,
+	completed numeric DEFAULT false,
+	priority integer NOT NULL DEFAULT 2,
+	due_at datetime,
+	remind_at datetime,
+	reminded_at datetime,
+	recurrence text,
+	occurrence integer,
+	series_id integer,
+	next_occurrence_id integer,
+	user_id integer,
+	project_id integer,
+	parent_id integer,
+	position integer,
+	CONSTRAINT fk_todos_user FOREIGN KEY (user_id) REFERENCES user_lites (id),
+	CONSTRAINT fk_todos_subtasks FOREIGN KEY (parent_id) REFERENCES todos (id)
+);
+CREATE INDEX IF NOT EXISTS idx_todos_deleted_at ON todos (deleted_at);
+CREATE INDEX IF NOT EXISTS idx_todos_priority ON todos (priority);
+CREATE INDEX IF NOT EXISTS idx_todos_due_at ON todos (due_at);
+CREATE INDEX IF NOT EXISTS idx_todos_remind_at ON todos (remind_at);
+CREATE INDEX IF NOT EXISTS idx_todos_series_id ON todos (series_id);
+CREATE INDEX IF NOT EXISTS idx_todos_project_id ON todos (project_id);
+CREATE INDEX IF NOT EXISTS idx_todos_parent_id ON todos (parent_id);
+
+CREATE TABLE IF NOT EXISTS tags (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime,
+	name text NOT NULL,
+	color text,
+	user_id integer NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tags_user_name ON tags (name, user_id);
+CREATE INDEX IF NOT EXISTS idx_tags_deleted_at ON tags (deleted_at);
+
+CREATE TABLE IF NOT EXISTS todo_tags (
+	todo_id integer,
+	tag_id integer,
+	PRIMARY KEY (todo_id, tag_id),
+	CONSTRAINT fk_todo_tags_todo FOREIGN KEY (todo_id) REFERENCES todos (id),
+	CONSTRAINT fk_todo_tags_tag FOREIGN KEY (tag_id) REFERENCES tags (id)
+);
+
+CREATE TABLE IF NOT EXISTS todo_shares (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime,
+	todo_id integer NOT NULL,
+	user_id integer NOT NULL,
+	role text NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_todo_shares_todo_user ON todo_shares (todo_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_todo_shares_deleted_at ON todo_shares (deleted_at);
+
+CREATE TABLE IF NOT EXISTS projects (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	created_at datetime,
+	updated_at datetime,
+	deleted_at datetime,
+	name text NOT NULL,
+	color text,
+	archived numeric DEFAULT false,
+	position integer,
+	user_id integer NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_projects_user_id ON projects (user_id);
+CREATE INDEX IF NOT EXISTS idx_projects_deleted_at ON projects (deleted_at);
+
+CREATE TABLE IF NOT EXISTS sessions (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	user_id integer NOT NULL,
+	user_agent text,
+	ip text,
+	created_at datetime,
+	last_seen_at datetime,
+	revoked_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	session_id integer NOT NULL,
+	user_id integer NOT NULL,
+	token_hash text NOT NULL,
+	expires_at datetime,
+	used_at datetime,
+	created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON refresh_tokens (session_id);
+
+CREATE TABLE IF NOT EXISTS personal_access_tokens (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	user_id integer NOT NULL,
+	name text NOT NULL,
+	prefix text,
+	token_hash text NOT NULL,
+	scopes text,
+	expires_at datetime,
+	last_used_at datetime,
+	created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_personal_access_tokens_token_hash ON personal_access_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_personal_access_tokens_user_id ON personal_access_tokens (user_id);
+
+CREATE TABLE IF NOT EXISTS one_time_tokens (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	user_id integer NOT NULL,
+	purpose text NOT NULL,
+	token_hash text NOT NULL,
+	expires_at datetime,
+	used_at datetime,
+	created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_one_time_tokens_token_hash ON one_time_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_one_time_tokens_user_id ON one_time_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_one_time_tokens_purpose ON one_time_tokens (purpose);
+
+CREATE TABLE IF NOT EXISTS recovery_codes (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	user_id integer NOT NULL,
+	code_hash text NOT NULL,
+	used_at datetime,
+	created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_recovery_codes_code_hash ON recovery_codes (code_hash);
+CREATE INDEX IF NOT EXISTS idx_recovery_codes_user_id ON recovery_codes (user_id);
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	user_id integer,
+	email text NOT NULL,
+	ip text,
+	user_agent text,
+	success numeric,
+	reason text,
+	created_at datetime
+);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_user_id ON login_attempts (user_id);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_email ON login_attempts (email);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip ON login_attempts (ip);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_created_at ON login_attempts (created_at);
+
+CREATE TABLE IF NOT EXISTS user_identities (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	user_id integer NOT NULL,
+	provider text NOT NULL,
+	subject text NOT NULL,
+	email text,
+	created_at datetime,
+	last_login_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_provider_subject ON user_identities (provider, subject);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
+
+CREATE TABLE IF NOT EXISTS o_id_c_login_states (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	state_hash text NOT NULL,
+	provider text NOT NULL,
+	nonce text NOT NULL,
+	code_verifier text NOT NULL,
+	link_user_id integer,
+	expires_at datetime,
+	created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_o_id_c_login_states_state_hash ON o_id_c_login_states (state_hash);
+CREATE INDEX IF NOT EXISTS idx_o_id_c_login_states_expires_at ON o_id_c_login_states (expires_at);
+
+CREATE TABLE IF NOT EXISTS signing_keys (
+	id integer PRIMARY KEY AUTOINCREMENT,
+	kid text NOT NULL,
+	algorithm text NOT NULL,
+	private_key text NOT NULL,
+	public_key text NOT NULL,
+	activates_at datetime,
+	expires_at datetime,
+	created_at datetime
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_signing_keys_kid ON signing_keys (kid);
+CREATE INDEX IF NOT EXISTS idx_signing_keys_activates_at ON signing_keys (activates_at);
+CREATE INDEX IF NOT EXISTS idx_signing_keys_expires_at ON signing_keys (expires_at);
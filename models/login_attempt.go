@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// LoginAttempt records one try at logging in, successful or not. Failed
+// attempts drive the login lockout and are shown to users and admins.
+type LoginAttempt struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	UserID    *uint     `json:"user_id" gorm:"index"` // Nil when the email does not belong to any account
+	Email     string    `json:"email" gorm:"not null;index"`
+	IP        string    `json:"ip" gorm:"index"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	Reason    string    `json:"reason,omitempty"` // Why a failed attempt failed
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
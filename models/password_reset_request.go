@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// PasswordResetRequest records one request for a password reset email,
+// whether or not the email belongs to an account. They throttle the forgot
+// password endpoint.
+type PasswordResetRequest struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	Email     string    `json:"email" gorm:"not null;index"`
+	IP        string    `json:"ip" gorm:"index"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+const (
+	ScopeTodosRead  = "todos:read"
+	ScopeTodosWrite = "todos:write"
+	ScopeAdmin      = "admin"
+)
+
+// PersonalAccessTokenPrefix starts every personal access token, which tells
+// them apart from JWTs and makes leaked tokens easy to scan for.
+const PersonalAccessTokenPrefix = "tdp_"
+
+// PersonalAccessToken is a long-lived token for scripts and other clients
+// that cannot keep a login session. Only a hash of the token is stored.
+type PersonalAccessToken struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"not null"`
+	Prefix     string     `json:"prefix"` // First characters of the token, to recognise it in listings
+	TokenHash  string     `json:"-" gorm:"not null;uniqueIndex"`
+	Scopes     []string   `json:"scopes" gorm:"serializer:json"`
+	ExpiresAt  *time.Time `json:"expires_at"` // Nil for tokens that never expire
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// IsValidScope reports whether scope is one a token can be granted.
+func IsValidScope(scope string) bool {
+	switch scope {
+	case ScopeTodosRead, ScopeTodosWrite, ScopeAdmin:
+		return true
+	}
+	return false
+}
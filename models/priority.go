@@ -0,0 +1,60 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Priority of a todo. It is stored as a number so todos can be compared and
+// sorted by priority, and is read and written as its name in JSON.
+type Priority int
+
+const (
+	PriorityLow Priority = iota + 1
+	PriorityMedium
+	PriorityHigh
+	PriorityUrgent
+)
+
+var priorityNames = map[Priority]string{
+	PriorityLow:    "low",
+	PriorityMedium: "medium",
+	PriorityHigh:   "high",
+	PriorityUrgent: "urgent",
+}
+
+// ParsePriority returns the priority with the given name.
+func ParsePriority(name string) (Priority, error) {
+	for priority, priorityName := range priorityNames {
+		if strings.EqualFold(name, priorityName) {
+			return priority, nil
+		}
+	}
+	return 0, fmt.Errorf("priority must be one of low, medium, high or urgent")
+}
+
+func (priority Priority) String() string {
+	return priorityNames[priority]
+}
+
+func (priority Priority) MarshalJSON() ([]byte, error) {
+	return json.Marshal(priority.String())
+}
+
+func (priority *Priority) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return fmt.Errorf("priority must be one of low, medium, high or urgent")
+	}
+	if name == "" {
+		*priority = 0
+		return nil
+	}
+	parsed, err := ParsePriority(name)
+	if err != nil {
+		return err
+	}
+	*priority = parsed
+	return nil
+}
@@ -0,0 +1,15 @@
+package models
+
+import "gorm.io/gorm"
+
+// Project groups a user's todos into a list.
+type Project struct {
+	gorm.Model
+	Name           string `json:"name" gorm:"not null"`
+	Color          string `json:"color"`
+	Archived       bool   `json:"archived" gorm:"default:false"`
+	Position       int    `json:"position"` // Order of the project among the user's projects
+	UserID         uint   `json:"user_id" gorm:"not null;index"`
+	OpenCount      int64  `json:"open_count" gorm:"-"`
+	CompletedCount int64  `json:"completed_count" gorm:"-"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// Session is one login of a user. Every refresh token rotated from that login
+// belongs to the same session, so revoking it signs the login out everywhere
+// its tokens went.
+type Session struct {
+	ID         uint       `json:"id" gorm:"primarykey"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	Current    bool       `json:"current" gorm:"-"` // Set when listing, true for the session making the request
+}
+
+// RefreshToken is a single-use token exchanged for a new access token. Only
+// a hash of the token is stored.
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	SessionID uint       `json:"session_id" gorm:"not null;index"`
+	UserID    uint       `json:"user_id" gorm:"not null"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"` // Set once the token has been rotated
+	CreatedAt time.Time  `json:"created_at"`
+}
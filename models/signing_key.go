@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// SigningKey is a key pair used to sign access tokens. Keys are published in
+// the JWKS ahead of ActivatesAt so other services pick them up before the
+// first token signed with them arrives, and stay published until ExpiresAt so
+// tokens signed before a rotation keep verifying.
+type SigningKey struct {
+	ID          uint       `json:"id" gorm:"primarykey"`
+	Kid         string     `json:"kid" gorm:"not null;uniqueIndex"`
+	Algorithm   string     `json:"algorithm" gorm:"not null"` // RS256 or EdDSA
+	PrivateKey  string     `json:"-" gorm:"not null"`         // PKCS #8 PEM
+	PublicKey   string     `json:"public_key" gorm:"not null"`
+	ActivatesAt time.Time  `json:"activates_at" gorm:"index"`
+	ExpiresAt   *time.Time `json:"expires_at" gorm:"index"` // Set once a newer key replaces this one
+	CreatedAt   time.Time  `json:"created_at"`
+}
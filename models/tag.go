@@ -0,0 +1,12 @@
+package models
+
+import "gorm.io/gorm"
+
+// Tag is a label a user can attach to todos. Tags are personal: each user
+// only sees their own tags, including on todos shared with them.
+type Tag struct {
+	gorm.Model
+	Name   string `json:"name" gorm:"not null;uniqueIndex:idx_tags_user_name"`
+	Color  string `json:"color"`
+	UserID uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_tags_user_name"`
+}
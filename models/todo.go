@@ -1,14 +1,38 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 type Todo struct {
 	gorm.Model
-	Title       string   `json:"title" gorm:"not null"`
-	Description string   `json:"description"`
-	Completed   bool     `json:"completed" gorm:"default:false"`
-	UserID      uint     `json:"user_id"`                                 // Foreign Key for the user model
-	User        UserLite `json:"user,omitempty" gorm:"foreignKey:UserID"` // User association
+	Title            string        `json:"title" gorm:"not null"`
+	Description      string        `json:"description"`
+	Completed        bool          `json:"completed" gorm:"default:false"`
+	Priority         Priority      `json:"priority" gorm:"not null;default:2;index"`
+	DueAt            *time.Time    `json:"due_at" gorm:"index"`
+	RemindAt         *time.Time    `json:"remind_at" gorm:"index"`
+	RemindedAt       *time.Time    `json:"reminded_at"`            // When the reminder was sent, nil while it is pending
+	Recurrence       string        `json:"recurrence"`             // RFC 5545 RRULE, empty for one-off todos
+	Occurrence       int           `json:"occurrence"`             // Number of this todo in its recurring series, starting at 1
+	SeriesID         *uint         `json:"series_id" gorm:"index"` // First todo of the recurring series
+	NextOccurrenceID *uint         `json:"next_occurrence_id"`     // Todo created when this occurrence was completed
+	UserID           uint          `json:"user_id"`                // Foreign Key for the user model
+	ProjectID        *uint         `json:"project_id" gorm:"index"`
+	User             UserLite      `json:"user,omitempty" gorm:"foreignKey:UserID"` // User association
+	Tags             []Tag         `json:"tags" gorm:"many2many:todo_tags;"`
+	ParentID         *uint         `json:"parent_id" gorm:"index"` // Set on subtasks, points to the todo they belong to
+	Position         int           `json:"position"`               // Order of a subtask among its siblings
+	Subtasks         []Todo        `json:"subtasks,omitempty" gorm:"foreignKey:ParentID"`
+	Progress         *TodoProgress `json:"progress,omitempty" gorm:"-"`
+}
+
+// TodoProgress counts the completed direct subtasks of a todo.
+type TodoProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
 }
 type UserLite struct {
 	ID       uint   `json:"id"`
@@ -0,0 +1,25 @@
+package models
+
+import "gorm.io/gorm"
+
+// Roles a user can hold on a todo. The creator of a todo is always its owner;
+// the other roles are granted through a TodoShare.
+const (
+	ShareRoleViewer = "viewer"
+	ShareRoleEditor = "editor"
+	ShareRoleOwner  = "owner"
+)
+
+type TodoShare struct {
+	gorm.Model
+	TodoID   uint   `json:"todo_id" gorm:"not null;uniqueIndex:idx_todo_shares_todo_user"`
+	UserID   uint   `json:"user_id" gorm:"not null;uniqueIndex:idx_todo_shares_todo_user"` // User the todo is shared with
+	Role     string `json:"role" gorm:"not null"`
+	UserName string `json:"username" gorm:"->;-:migration"` // Filled from the users table when listing
+	Email    string `json:"email" gorm:"->;-:migration"`
+}
+
+// IsValidShareRole reports whether role can be granted through a share.
+func IsValidShareRole(role string) bool {
+	return role == ShareRoleViewer || role == ShareRoleEditor || role == ShareRoleOwner
+}
@@ -2,12 +2,39 @@ package models
 
 import "time"
 
+const (
+	RoleUser    = "user"
+	RoleSupport = "support" // Can look up users but not change them
+	RoleAdmin   = "admin"
+)
+
 type User struct {
-	ID        uint      `json:"_id" gorm:"primarykey"`
-	Name      string    `json:"name"`
-	UserName  string    `json:"username"`
-	Email     string    `json:"email" gorm:"unique"`
-	Password  string    `json:"password"`
-	CreatedAt time.Time `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
-	UpdatedAt time.Time `json:"updated_at" gorm:"default:null"`
+	ID              uint       `json:"_id" gorm:"primarykey"`
+	Name            string     `json:"name"`
+	UserName        string     `json:"username"`
+	Email           string     `json:"email" gorm:"unique"`
+	Password        string     `json:"password"`
+	TimeZone        string     `json:"time_zone" gorm:"default:UTC"` // IANA time zone used to read and show dates
+	Role            string     `json:"role" gorm:"not null;default:user;index"`
+	DisabledAt      *time.Time `json:"disabled_at"`                  // Set while an admin has disabled the account
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`            // Nil until the user confirms they own Email
+	TOTPSecret      string     `json:"-"`                            // Base32 secret, set from enrollment on
+	TOTPEnabledAt   *time.Time `json:"two_factor_enabled_at"`        // Set once enrollment is confirmed with a code
+	TOTPLastStep    int64      `json:"-"`                            // Time step of the last accepted code, which cannot be used again
+	DeletionDueAt   *time.Time `json:"deletion_due_at" gorm:"index"` // Set while the account is scheduled for deletion
+	CreatedAt       time.Time  `json:"created_at" gorm:"default:CURRENT_TIMESTAMP"`
+	UpdatedAt       time.Time  `json:"updated_at" gorm:"default:null"`
+}
+
+// IsValidRole reports whether role is one a user can be given.
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleSupport || role == RoleAdmin
+}
+
+// Location returns the user's time zone, falling back to UTC.
+func (user *User) Location() *time.Location {
+	if location, err := time.LoadLocation(user.TimeZone); err == nil && user.TimeZone != "" {
+		return location
+	}
+	return time.UTC
 }
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// UserIdentity links a user to an account at an external identity provider.
+type UserIdentity struct {
+	ID          uint       `json:"id" gorm:"primarykey"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	Provider    string     `json:"provider" gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"`
+	Subject     string     `json:"subject" gorm:"not null;uniqueIndex:idx_user_identities_provider_subject"` // The provider's "sub" claim
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// OIDCLoginState remembers an authorization request between sending the user
+// to the provider and the callback. It is deleted when the callback uses it.
+type OIDCLoginState struct {
+	ID           uint      `gorm:"primarykey"`
+	StateHash    string    `gorm:"not null;uniqueIndex"`
+	Provider     string    `gorm:"not null"`
+	Nonce        string    `gorm:"not null"`
+	CodeVerifier string    `gorm:"not null"`
+	LinkUserID   *uint     // Set when a logged in user is linking the identity to their account
+	ExpiresAt    time.Time `gorm:"index"`
+	CreatedAt    time.Time
+}
@@ -0,0 +1,20 @@
+package notifiers
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// LogNotifier writes reminders to the server log. It is meant for local
+// development.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(ctx context.Context, reminder Reminder) error {
+	due := "no due date"
+	if reminder.Todo.DueAt != nil {
+		due = "due " + reminder.Todo.DueAt.In(reminder.User.Location()).Format(time.RFC1123)
+	}
+	log.Printf("Reminder for %s <%s>: %q (%s)", reminder.User.UserName, reminder.User.Email, reminder.Todo.Title, due)
+	return nil
+}
@@ -0,0 +1,36 @@
+package notifiers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/mailers"
+)
+
+// MailNotifier emails reminders through the same senders as account emails.
+type MailNotifier struct {
+	Sender mailers.Sender
+}
+
+func (notifier *MailNotifier) Notify(ctx context.Context, reminder Reminder) error {
+	return notifier.Sender.Send(ctx, message(reminder))
+}
+
+func message(reminder Reminder) mailers.Message {
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s,\n\nThis is your reminder for %q.\n", reminder.User.Name, reminder.Todo.Title)
+	if reminder.Todo.DueAt != nil {
+		fmt.Fprintf(&body, "It is due %s.\n", reminder.Todo.DueAt.In(reminder.User.Location()).Format(time.RFC1123))
+	}
+	if reminder.Todo.Description != "" {
+		fmt.Fprintf(&body, "\n%s\n", reminder.Todo.Description)
+	}
+
+	return mailers.Message{
+		To:      reminder.User.Email,
+		Subject: "Reminder: " + reminder.Todo.Title,
+		Body:    body.String(),
+	}
+}
@@ -0,0 +1,34 @@
+package notifiers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Waris-Shaik/todo-backend/config"
+	"github.com/Waris-Shaik/todo-backend/mailers"
+	"github.com/Waris-Shaik/todo-backend/models"
+)
+
+// Reminder is sent when a todo's reminder time has come.
+type Reminder struct {
+	Todo models.Todo
+	User models.User
+}
+
+// Notifier delivers todo reminders to their owner.
+type Notifier interface {
+	Notify(ctx context.Context, reminder Reminder) error
+}
+
+// FromConfig builds the notifier selected by reminders.notifier, "log" (the
+// default) or "smtp".
+func FromConfig(cfg *config.Config) (Notifier, error) {
+	switch kind := cfg.Reminders.Notifier; kind {
+	case "log":
+		return LogNotifier{}, nil
+	case "smtp":
+		return &MailNotifier{Sender: mailers.NewSMTPSender(cfg.SMTP)}, nil
+	default:
+		return nil, fmt.Errorf("unknown reminder notifier %q", kind)
+	}
+}
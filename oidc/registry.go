@@ -0,0 +1,66 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+var ErrUnknownProvider = errors.New("unknown identity provider")
+
+// Registry holds the configured providers and discovers each one the first
+// time it is used, so a provider that is down does not stop the server.
+type Registry struct {
+	Client *http.Client // Used for discovery, token and JWKS requests, nil for a default client
+
+	mu        sync.Mutex
+	configs   map[string]Config
+	providers map[string]*Provider
+}
+
+func NewRegistry(configs ...Config) *Registry {
+	registry := &Registry{
+		configs:   make(map[string]Config, len(configs)),
+		providers: make(map[string]*Provider, len(configs)),
+	}
+	for _, config := range configs {
+		registry.configs[config.Name] = config
+	}
+	return registry
+}
+
+// Names lists the configured providers.
+func (registry *Registry) Names() []string {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	names := make([]string, 0, len(registry.configs))
+	for name := range registry.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the named provider, running discovery if it has not been run.
+func (registry *Registry) Get(ctx context.Context, name string) (*Provider, error) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if provider, ok := registry.providers[name]; ok {
+		return provider, nil
+	}
+	config, ok := registry.configs[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+
+	provider, err := Discover(ctx, config, registry.Client)
+	if err != nil {
+		return nil, err
+	}
+	registry.providers[name] = provider
+	return provider, nil
+}
@@ -0,0 +1,277 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/models"
+	"gorm.io/gorm"
+)
+
+type gormTodoRepository struct {
+	db *gorm.DB
+}
+
+// withRelations preloads the owner and the viewer's tags.
+func (repo *gormTodoRepository) withRelations(viewerID uint) *gorm.DB {
+	return repo.db.Preload("User").Preload("Tags", "user_id = ?", viewerID)
+}
+
+func (repo *gormTodoRepository) Create(todo *models.Todo) error {
+	return repo.db.Create(todo).Error
+}
+
+func (repo *gormTodoRepository) FindByID(id uint, viewerID uint) (models.Todo, error) {
+	var todo models.Todo
+	return todo, notFound(repo.withRelations(viewerID).First(&todo, id).Error)
+}
+
+func (repo *gormTodoRepository) List(userID uint, query TodoListQuery) (TodoPage, error) {
+	// Count every todo matching the filters
+	var total int64
+	result := filterTodos(repo.db.Model(&models.Todo{}).Where("todos.user_id = ? AND todos.parent_id IS NULL", userID), query).Count(&total)
+	if result.Error != nil {
+		return TodoPage{}, result.Error
+	}
+
+	// Retreive the page
+	var todos []models.Todo
+	result = pageTodos(filterTodos(repo.withRelations(userID).Where("todos.user_id = ? AND todos.parent_id IS NULL", userID), query), query).Find(&todos)
+	if result.Error != nil {
+		return TodoPage{}, result.Error
+	}
+
+	return query.page(todos, total), nil
+}
+
+func (repo *gormTodoRepository) ListDue(userID uint, from *time.Time, to time.Time) ([]models.Todo, error) {
+	db := repo.withRelations(userID).Where("user_id = ? AND completed = ? AND due_at < ?", userID, false, to)
+	if from != nil {
+		db = db.Where("due_at >= ?", *from)
+	}
+
+	var todos []models.Todo
+	return todos, db.Order("due_at ASC").Find(&todos).Error
+}
+
+func (repo *gormTodoRepository) Subtasks(parentIDs []uint, viewerID uint) ([]models.Todo, error) {
+	var children []models.Todo
+	result := repo.withRelations(viewerID).
+		Where("parent_id IN ?", parentIDs).
+		Order("position ASC").Order("id ASC").
+		Find(&children)
+	return children, result.Error
+}
+
+func (repo *gormTodoRepository) DescendantIDs(id uint) ([]uint, error) {
+	return descendantIDs(repo.db, id)
+}
+
+// descendantIDs walks the subtasks below the todo. An unscoped db includes
+// subtasks in the trash.
+func descendantIDs(db *gorm.DB, id uint) ([]uint, error) {
+	var ids []uint
+	level := []uint{id}
+	for depth := 0; len(level) > 0 && depth < MaxSubtaskDepth; depth++ {
+		var children []uint
+		if result := db.Model(&models.Todo{}).Where("parent_id IN ?", level).Pluck("id", &children); result.Error != nil {
+			return nil, result.Error
+		}
+		ids = append(ids, children...)
+		level = children
+	}
+	return ids, nil
+}
+
+func (repo *gormTodoRepository) Update(todo *models.Todo, changes map[string]interface{}) error {
+	return repo.db.Model(todo).Updates(changes).Error
+}
+
+func (repo *gormTodoRepository) SetCompleted(todo *models.Todo, completed bool, subtaskIDs []uint, next *models.Todo) error {
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		if result := tx.Model(todo).Update("completed", completed); result.Error != nil {
+			return result.Error
+		}
+		if len(subtaskIDs) > 0 {
+			if result := tx.Model(&models.Todo{}).Where("id IN ?", subtaskIDs).Update("completed", completed); result.Error != nil {
+				return result.Error
+			}
+		}
+		if next == nil {
+			return nil
+		}
+
+		if result := tx.Omit("User", "Tags", "Subtasks").Create(next); result.Error != nil {
+			return result.Error
+		}
+
+		// Carry over the tags and shares
+		var tagIDs []uint
+		if result := tx.Table("todo_tags").Where("todo_id = ?", todo.ID).Pluck("tag_id", &tagIDs); result.Error != nil {
+			return result.Error
+		}
+		for _, tagID := range tagIDs {
+			if result := tx.Exec("INSERT INTO todo_tags (todo_id, tag_id) VALUES (?, ?)", next.ID, tagID); result.Error != nil {
+				return result.Error
+			}
+		}
+
+		var shares []models.TodoShare
+		if result := tx.Where("todo_id = ?", todo.ID).Find(&shares); result.Error != nil {
+			return result.Error
+		}
+		for _, share := range shares {
+			copied := models.TodoShare{TodoID: next.ID, UserID: share.UserID, Role: share.Role}
+			if result := tx.Create(&copied); result.Error != nil {
+				return result.Error
+			}
+		}
+
+		// Remember the next occurrence so completing this todo again does not
+		// create another one
+		if result := tx.Model(todo).Update("next_occurrence_id", next.ID); result.Error != nil {
+			return result.Error
+		}
+		todo.NextOccurrenceID = &next.ID
+		return nil
+	})
+}
+
+func (repo *gormTodoRepository) Delete(ids []uint) error {
+	return repo.db.Where("id IN ?", ids).Delete(&models.Todo{}).Error
+}
+
+func (repo *gormTodoRepository) ListTrash(userID uint) ([]models.Todo, error) {
+	var todos []models.Todo
+	result := repo.db.Unscoped().Preload("User").
+		Joins("LEFT JOIN todos AS parents ON parents.id = todos.parent_id").
+		Where("todos.user_id = ? AND todos.deleted_at IS NOT NULL", userID).
+		Where("todos.parent_id IS NULL OR parents.deleted_at IS NULL").
+		Order("todos.deleted_at DESC").
+		Find(&todos)
+	return todos, result.Error
+}
+
+func (repo *gormTodoRepository) FindWithTrashed(id uint) (models.Todo, error) {
+	var todo models.Todo
+	return todo, notFound(repo.db.Unscoped().Preload("User").First(&todo, id).Error)
+}
+
+func (repo *gormTodoRepository) Restore(id uint) error {
+	subtaskIDs, err := descendantIDs(repo.db.Unscoped(), id)
+	if err != nil {
+		return err
+	}
+	return repo.db.Unscoped().Model(&models.Todo{}).Where("id IN ?", append(subtaskIDs, id)).Update("deleted_at", nil).Error
+}
+
+func (repo *gormTodoRepository) Purge(id uint) error {
+	subtaskIDs, err := descendantIDs(repo.db.Unscoped(), id)
+	if err != nil {
+		return err
+	}
+	return repo.db.Transaction(func(tx *gorm.DB) error {
+		return PurgeTodos(tx, append(subtaskIDs, id))
+	})
+}
+
+// PurgeTodos permanently deletes the todos, whether trashed or not, along
+// with their tags and shares. Callers include the subtasks themselves.
+func PurgeTodos(tx *gorm.DB, ids []uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if result := tx.Exec("DELETE FROM todo_tags WHERE todo_id IN ?", ids); result.Error != nil {
+		return result.Error
+	}
+	if result := tx.Unscoped().Where("todo_id IN ?", ids).Delete(&models.TodoShare{}); result.Error != nil {
+		return result.Error
+	}
+	return tx.Unscoped().Where("id IN ?", ids).Delete(&models.Todo{}).Error
+}
+
+// filterTodos narrows db down to the todos matching the query filters. It
+// does not apply the cursor, so it can also be used to count every match.
+func filterTodos(db *gorm.DB, query TodoListQuery) *gorm.DB {
+	if query.Completed != nil {
+		db = db.Where("todos.completed = ?", *query.Completed)
+	}
+	if query.Search != "" {
+		pattern := LikePattern(query.Search)
+		db = db.Where(`LOWER(todos.title) LIKE ? ESCAPE '\' OR LOWER(todos.description) LIKE ? ESCAPE '\'`, pattern, pattern)
+	}
+	if query.Title != "" {
+		db = db.Where(`LOWER(todos.title) LIKE ? ESCAPE '\'`, LikePattern(query.Title))
+	}
+	if query.Description != "" {
+		db = db.Where(`LOWER(todos.description) LIKE ? ESCAPE '\'`, LikePattern(query.Description))
+	}
+	if query.CreatedAfter != nil {
+		db = db.Where("todos.created_at >= ?", *query.CreatedAfter)
+	}
+	if query.CreatedBefore != nil {
+		db = db.Where("todos.created_at < ?", *query.CreatedBefore)
+	}
+	if query.UpdatedAfter != nil {
+		db = db.Where("todos.updated_at >= ?", *query.UpdatedAfter)
+	}
+	if query.UpdatedBefore != nil {
+		db = db.Where("todos.updated_at < ?", *query.UpdatedBefore)
+	}
+	if len(query.Priorities) > 0 {
+		db = db.Where("todos.priority IN ?", query.Priorities)
+	}
+	if query.MinPriority != 0 {
+		db = db.Where("todos.priority >= ?", query.MinPriority)
+	}
+	if query.MaxPriority != 0 {
+		db = db.Where("todos.priority <= ?", query.MaxPriority)
+	}
+	if query.ProjectID != nil {
+		db = db.Where("todos.project_id = ?", *query.ProjectID)
+	}
+	if query.NoProject {
+		db = db.Where("todos.project_id IS NULL")
+	}
+	if len(query.Tags) > 0 {
+		tagged := db.Session(&gorm.Session{NewDB: true}).
+			Table("todo_tags").
+			Select("todo_tags.todo_id").
+			Joins("JOIN tags ON tags.id = todo_tags.tag_id").
+			Where("tags.user_id = ? AND tags.name IN ? AND tags.deleted_at IS NULL", query.UserID, query.Tags)
+		if query.MatchAllTags {
+			tagged = tagged.Group("todo_tags.todo_id").Having("COUNT(DISTINCT tags.name) = ?", len(uniqueStrings(query.Tags)))
+		}
+		db = db.Where("todos.id IN (?)", tagged)
+	}
+	return db
+}
+
+// pageTodos orders db by the requested sort, skips past the cursor and
+// limits the result to one more todo than the page size so the caller can
+// tell whether another page follows.
+func pageTodos(db *gorm.DB, query TodoListQuery) *gorm.DB {
+	column := "todos." + TodoSortColumns[query.sortField()]
+	direction, comparison := "ASC", ">"
+	if query.descending() {
+		direction, comparison = "DESC", "<"
+	}
+
+	if query.Cursor != nil {
+		value := query.cursorValue()
+		db = db.Where(
+			fmt.Sprintf("%s %s ? OR (%s = ? AND todos.id %s ?)", column, comparison, column, comparison),
+			value, value, query.Cursor.ID,
+		)
+	}
+
+	return db.Order(column + " " + direction).Order("todos.id " + direction).Limit(query.Limit + 1)
+}
+
+// LikePattern builds a case-insensitive substring pattern for LIKE, escaping
+// the wildcard characters in the search term.
+func LikePattern(term string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(term))
+	return "%" + escaped + "%"
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/Waris-Shaik/todo-backend/models"
+	"gorm.io/gorm"
+)
+
+type gormUserRepository struct {
+	db *gorm.DB
+}
+
+func (repo *gormUserRepository) FindByID(id uint) (models.User, error) {
+	var user models.User
+	return user, notFound(repo.db.First(&user, id).Error)
+}
+
+func (repo *gormUserRepository) FindByEmail(email string) (models.User, error) {
+	var user models.User
+	return user, notFound(repo.db.Where("email = ?", email).First(&user).Error)
+}
+
+func (repo *gormUserRepository) List() ([]models.User, error) {
+	var users []models.User
+	return users, repo.db.Order("id ASC").Find(&users).Error
+}
+
+func (repo *gormUserRepository) Create(user *models.User) error {
+	return repo.db.Create(user).Error
+}
+
+func (repo *gormUserRepository) Update(user *models.User, changes map[string]interface{}) error {
+	return repo.db.Model(user).Updates(changes).Error
+}
+
+// notFound reports GORM's missing record error as ErrNotFound.
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// MaxSubtaskDepth is how many levels of subtasks a todo can have.
+const MaxSubtaskDepth = 10
+
+var ErrNotFound = errors.New("record not found")
+
+// UserRepository stores user accounts.
+type UserRepository interface {
+	FindByID(id uint) (models.User, error)
+	FindByEmail(email string) (models.User, error)
+	List() ([]models.User, error)
+	Create(user *models.User) error
+	// Update sets the given columns, such as "name" or "email_verified_at",
+	// and applies them to user.
+	Update(user *models.User, changes map[string]interface{}) error
+}
+
+// TodoRepository stores todos. Todos are returned with their owner and with
+// the tags the viewing user put on them.
+type TodoRepository interface {
+	Create(todo *models.Todo) error
+	FindByID(id uint, viewerID uint) (models.Todo, error)
+	// List returns a page of the user's top-level todos matching query.
+	List(userID uint, query TodoListQuery) (TodoPage, error)
+	// ListDue returns the user's open todos due in [from, to), soonest first.
+	// A nil from has no lower bound.
+	ListDue(userID uint, from *time.Time, to time.Time) ([]models.Todo, error)
+	// Subtasks returns the direct subtasks of the given todos in order.
+	Subtasks(parentIDs []uint, viewerID uint) ([]models.Todo, error)
+	// DescendantIDs returns the IDs of every subtask below the todo.
+	DescendantIDs(id uint) ([]uint, error)
+	// Update sets the given columns, such as "title" or "due_at", and
+	// applies them to todo.
+	Update(todo *models.Todo, changes map[string]interface{}) error
+	// SetCompleted marks the todo and the given subtasks as completed or not.
+	// When next is given it is created along with it, as the following
+	// occurrence of the series, with the todo's tags and shares.
+	SetCompleted(todo *models.Todo, completed bool, subtaskIDs []uint, next *models.Todo) error
+	// Delete moves the todos to the trash.
+	Delete(ids []uint) error
+	// ListTrash returns the user's trashed todos, most recently trashed first.
+	// Subtasks trashed along with their parent are left out.
+	ListTrash(userID uint) ([]models.Todo, error)
+	// FindWithTrashed finds a todo whether it is in the trash or not.
+	FindWithTrashed(id uint) (models.Todo, error)
+	// Restore brings the todo back from the trash with its subtasks.
+	Restore(id uint) error
+	// Purge permanently deletes the todo with its subtasks, trashed or not.
+	Purge(id uint) error
+}
+
+// Repositories groups the repositories handlers use.
+type Repositories struct {
+	Users UserRepository
+	Todos TodoRepository
+}
+
+// New returns repositories backed by a GORM database, Postgres or SQLite.
+func New(db *gorm.DB) Repositories {
+	return Repositories{
+		Users: &gormUserRepository{db: db},
+		Todos: &gormTodoRepository{db: db},
+	}
+}
+
+// FromContext returns the repositories attached to the request by
+// middlewares.UseRepositories.
+func FromContext(ctx *gin.Context) Repositories {
+	return ctx.MustGet("repositories").(Repositories)
+}
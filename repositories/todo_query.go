@@ -0,0 +1,143 @@
+package repositories
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/models"
+)
+
+// TodoSortColumns maps the values accepted by the sort parameter (without a
+// leading "-") to the column they sort on.
+var TodoSortColumns = map[string]string{
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+	"title":      "title",
+	"priority":   "priority",
+}
+
+// TodoListQuery holds the filters, sorting and pagination requested when
+// listing todos.
+type TodoListQuery struct {
+	UserID        uint // User whose tags are matched by Tags
+	Limit         int
+	Sort          string
+	Cursor        *TodoCursor
+	Completed     *bool
+	Search        string
+	Title         string
+	Description   string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	Tags          []string
+	MatchAllTags  bool
+	Priorities    []models.Priority
+	MinPriority   models.Priority
+	MaxPriority   models.Priority
+	ProjectID     *uint
+	NoProject     bool // Only todos that are not in a project
+}
+
+// TodoCursor marks the position of the last todo of a page. It is handed to
+// clients as an opaque string.
+type TodoCursor struct {
+	Sort  string `json:"s"`
+	Value string `json:"v"`
+	ID    uint   `json:"id"`
+}
+
+// TodoPage is one page of a todo listing.
+type TodoPage struct {
+	Todos      []models.Todo
+	Total      int64  // Todos matching the filters across all pages
+	NextCursor string // Empty on the last page
+}
+
+// descending reports whether the query sorts newest, highest or last first.
+func (query TodoListQuery) descending() bool {
+	return strings.HasPrefix(query.Sort, "-")
+}
+
+// sortField is the sort without its direction.
+func (query TodoListQuery) sortField() string {
+	return strings.TrimPrefix(query.Sort, "-")
+}
+
+// page trims the extra todo fetched to look ahead and returns the cursor
+// for the following page, or an empty string when this is the last one.
+func (query TodoListQuery) page(todos []models.Todo, total int64) TodoPage {
+	if len(todos) <= query.Limit {
+		return TodoPage{Todos: todos, Total: total}
+	}
+	todos = todos[:query.Limit]
+	last := todos[len(todos)-1]
+
+	cursor := TodoCursor{Sort: query.Sort, ID: last.ID}
+	switch query.sortField() {
+	case "created_at":
+		cursor.Value = last.CreatedAt.UTC().Format(time.RFC3339Nano)
+	case "updated_at":
+		cursor.Value = last.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case "title":
+		cursor.Value = last.Title
+	case "priority":
+		cursor.Value = strconv.Itoa(int(last.Priority))
+	}
+
+	encoded, _ := json.Marshal(cursor)
+	return TodoPage{Todos: todos, Total: total, NextCursor: base64.RawURLEncoding.EncodeToString(encoded)}
+}
+
+// cursorValue converts the cursor value back to the type of its column.
+func (query TodoListQuery) cursorValue() interface{} {
+	switch query.sortField() {
+	case "title":
+		return query.Cursor.Value
+	case "priority":
+		value, _ := strconv.Atoi(query.Cursor.Value)
+		return value
+	}
+	value, _ := time.Parse(time.RFC3339Nano, query.Cursor.Value)
+	return value
+}
+
+// DecodeTodoCursor reads a cursor handed out with a previous page.
+func DecodeTodoCursor(cursor string) (TodoCursor, error) {
+	var decoded TodoCursor
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return decoded, err
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return decoded, err
+	}
+	switch strings.TrimPrefix(decoded.Sort, "-") {
+	case "title":
+	case "priority":
+		if _, err := strconv.Atoi(decoded.Value); err != nil {
+			return decoded, err
+		}
+	default:
+		if _, err := time.Parse(time.RFC3339Nano, decoded.Value); err != nil {
+			return decoded, err
+		}
+	}
+	return decoded, nil
+}
+
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := values[:0:0]
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/migrations"
+)
+
+func TestHealthAndReadiness(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.client()
+
+	c.get("/healthz").expectMessage(http.StatusOK, "ok")
+	checks := c.get("/readyz").expectMessage(http.StatusOK, "ready").object("checks")
+	for _, name := range []string{"database", "schema", "signing_keys"} {
+		if checks[name] != "ok" {
+			t.Errorf("check %s = %v, want ok", name, checks[name])
+		}
+	}
+
+	// Until the last migration is applied the schema is not ready
+	if _, err := migrations.Down(initializers.DB, 1); err != nil {
+		t.Fatal(err)
+	}
+	checks = c.get("/readyz").expectMessage(http.StatusServiceUnavailable, "not ready").object("checks")
+	if checks["database"] != "ok" || checks["schema"] != "failed" {
+		t.Errorf("checks = %v, want only the schema failed", checks)
+	}
+
+	// Without a database the server is alive but not ready
+	sqlDB, err := initializers.DB.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB.Close()
+	c.get("/healthz").expect(http.StatusOK)
+	checks = c.get("/readyz").expectMessage(http.StatusServiceUnavailable, "not ready").object("checks")
+	if checks["database"] != "failed" {
+		t.Errorf("database check = %v, want failed", checks["database"])
+	}
+}
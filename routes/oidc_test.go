@@ -0,0 +1,240 @@
+package routes
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/oidc"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const testClientID = "todo-client"
+
+// authRequest is what the mock issuer remembers about an authorization code.
+type authRequest struct {
+	challenge string
+	nonce     string
+}
+
+// mockIssuer is an OpenID provider that approves every authorization request
+// straight away, for the subject it is set up with.
+type mockIssuer struct {
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	subject string
+	email   string
+
+	mu     sync.Mutex
+	codes  map[string]authRequest
+	issued int                // Codes handed out so far
+	tamper func(*authRequest) // Changes what the next code is issued for, when set
+}
+
+// newMockIssuer starts an issuer and registers it with the API as the
+// "mock" provider.
+func newMockIssuer(ts *testServer) *mockIssuer {
+	ts.t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	issuer := &mockIssuer{key: key, subject: "mock-user-1", email: "mock@example.com", codes: map[string]authRequest{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", issuer.discovery)
+	mux.HandleFunc("/authorize", issuer.authorize)
+	mux.HandleFunc("/token", issuer.token)
+	mux.HandleFunc("/jwks", issuer.jwks)
+	issuer.server = httptest.NewServer(mux)
+
+	previous := initializers.OIDC
+	initializers.OIDC = oidc.NewRegistry(oidc.Config{
+		Name:        "mock",
+		Issuer:      issuer.server.URL,
+		ClientID:    testClientID,
+		RedirectURL: ts.server.URL + "/api/v1/auth/oidc/mock/callback",
+	})
+	ts.t.Cleanup(func() {
+		issuer.server.Close()
+		initializers.OIDC = previous
+	})
+	return issuer
+}
+
+func (issuer *mockIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]string{
+		"issuer":                 issuer.server.URL,
+		"authorization_endpoint": issuer.server.URL + "/authorize",
+		"token_endpoint":         issuer.server.URL + "/token",
+		"jwks_uri":               issuer.server.URL + "/jwks",
+	})
+}
+
+// authorize approves the request and sends the browser back with a code.
+func (issuer *mockIssuer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	request := authRequest{challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+
+	issuer.mu.Lock()
+	issuer.issued++
+	code := "code-" + strconv.Itoa(issuer.issued)
+	if issuer.tamper != nil {
+		issuer.tamper(&request)
+		issuer.tamper = nil
+	}
+	issuer.codes[code] = request
+	issuer.mu.Unlock()
+
+	callback := query.Get("redirect_uri") + "?" + url.Values{"code": {code}, "state": {query.Get("state")}}.Encode()
+	http.Redirect(w, r, callback, http.StatusFound)
+}
+
+// token checks the PKCE verifier and returns a signed ID token.
+func (issuer *mockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	issuer.mu.Lock()
+	request, ok := issuer.codes[r.PostForm.Get("code")]
+	delete(issuer.codes, r.PostForm.Get("code"))
+	issuer.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if !ok || base64.RawURLEncoding.EncodeToString(challenge[:]) != request.challenge {
+		http.Error(w, `{"error":"invalid_grant"}`, http.StatusBadRequest)
+		return
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            issuer.server.URL,
+		"aud":            testClientID,
+		"sub":            issuer.subject,
+		"email":          issuer.email,
+		"email_verified": true,
+		"name":           "Mock User",
+		"nonce":          request.nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(time.Minute).Unix(),
+	})
+	token.Header["kid"] = "mock-key"
+	idToken, err := token.SignedString(issuer.key)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]string{"id_token": idToken, "token_type": "Bearer"})
+}
+
+func (issuer *mockIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "mock-key",
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(issuer.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(issuer.key.E)).Bytes()),
+		}},
+	})
+}
+
+// beginLogin starts an OIDC login with c and returns the callback path the
+// provider sends the browser back to, without following it.
+func beginLogin(ts *testServer, c *client) string {
+	ts.t.Helper()
+
+	c.http.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if strings.HasSuffix(req.URL.Path, "/callback") {
+			return http.ErrUseLastResponse
+		}
+		return nil
+	}
+	defer func() { c.http.CheckRedirect = nil }()
+
+	res := c.get("/api/v1/auth/oidc/mock/login").expect(http.StatusFound)
+	return strings.TrimPrefix(res.Header.Get("Location"), ts.server.URL)
+}
+
+func TestOIDCLogin(t *testing.T) {
+	ts := newTestServer(t)
+	issuer := newMockIssuer(ts)
+
+	// The state cookie is kept from scripts and only sent to the OIDC routes
+	c := ts.client()
+	callback := beginLogin(ts, c)
+	if c.cookie("/api/v1/auth/oidc/mock/callback", "oidc_state") == "" || c.cookie("/api/v1/todos/my", "oidc_state") != "" {
+		t.Fatal("oidc_state cookie is not limited to the OIDC routes")
+	}
+	res := c.get(callback).expect(http.StatusOK)
+	if res.cookie("token") == nil {
+		t.Fatal("logging in with the provider did not set the session cookies")
+	}
+	if me := c.get("/api/v1/users/me").object("user"); me["email"] != issuer.email || me["email_verified_at"] == nil {
+		t.Fatalf("logged in as %v, want the provider's verified email", me)
+	}
+
+	// Each state works once, even when the browser still has its cookie
+	u, _ := url.Parse(ts.server.URL + "/api/v1/auth/oidc")
+	c.http.Jar.SetCookies(u, []*http.Cookie{{Name: "oidc_state", Value: mustQuery(t, callback, "state"), Path: "/api/v1/auth/oidc"}})
+	c.get(callback).expectMessage(http.StatusBadRequest, "login request is invalid")
+
+	// A callback started by someone else's browser is refused, so nobody can
+	// be logged in to or linked with an account that is not theirs
+	attacker := ts.client()
+	victim := ts.signUp("alice")
+	victim.get(beginLogin(ts, attacker)).expectMessage(http.StatusBadRequest, "login request is invalid")
+	if me := victim.get("/api/v1/users/me").object("user"); me["email"] != "alice@example.com" {
+		t.Fatalf("victim is logged in as %v", me["email"])
+	}
+}
+
+func TestOIDCLoginPromotesAdmins(t *testing.T) {
+	ts := newTestServer(t)
+	issuer := newMockIssuer(ts)
+	previous := initializers.Config.Auth.AdminEmails
+	initializers.Config.Auth.AdminEmails = []string{issuer.email}
+	t.Cleanup(func() { initializers.Config.Auth.AdminEmails = previous })
+
+	// The provider verified the listed address, so the new user is an admin
+	c := ts.client()
+	c.get(beginLogin(ts, c)).expect(http.StatusOK)
+	c.get("/api/v1/users/all").expect(http.StatusOK)
+}
+
+func TestOIDCLoginRejectsTamperedCodes(t *testing.T) {
+	ts := newTestServer(t)
+	issuer := newMockIssuer(ts)
+
+	// The code was issued for another PKCE challenge, so our verifier does not
+	// match it
+	issuer.tamper = func(request *authRequest) { request.challenge = "someone-elses-challenge" }
+	c := ts.client()
+	c.get(beginLogin(ts, c)).expectMessage(http.StatusUnauthorized, "could not verify the login")
+
+	// The ID token carries another login's nonce
+	issuer.tamper = func(request *authRequest) { request.nonce = "someone-elses-nonce" }
+	c = ts.client()
+	c.get(beginLogin(ts, c)).expectMessage(http.StatusUnauthorized, "could not verify the login")
+	c.get("/api/v1/users/me").expect(http.StatusUnauthorized)
+}
+
+func mustQuery(t *testing.T, path string, key string) string {
+	t.Helper()
+	u, err := url.Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query().Get(key)
+}
@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/jobs"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/notifiers"
+)
+
+// notifications records the reminders sent to it.
+type notifications struct {
+	mu   sync.Mutex
+	sent []notifiers.Reminder
+}
+
+func (n *notifications) Notify(ctx context.Context, reminder notifiers.Reminder) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.sent = append(n.sent, reminder)
+	return nil
+}
+
+func TestRemindersSkipLockedOutUsers(t *testing.T) {
+	ts := newTestServer(t)
+	for _, username := range []string{"alice", "bob", "carol"} {
+		ts.signUp(username).post("/api/v1/todos/new", map[string]interface{}{"title": "Call " + username, "description": "Soon"}).
+			expect(http.StatusCreated)
+	}
+	initializers.DB.Model(&models.Todo{}).Where("1 = 1").Update("remind_at", time.Now().Add(-time.Minute))
+
+	// Bob is disabled and Carol's account is about to be deleted
+	initializers.DB.Model(&models.User{}).Where("email = ?", "bob@example.com").Update("disabled_at", time.Now())
+	initializers.DB.Model(&models.User{}).Where("email = ?", "carol@example.com").Update("deletion_due_at", time.Now().Add(time.Hour))
+
+	notifier := &notifications{}
+	jobs.SendDueReminders(context.Background(), notifier)
+	if len(notifier.sent) != 1 || notifier.sent[0].User.Email != "alice@example.com" {
+		t.Fatalf("sent %d reminders, want only alice's", len(notifier.sent))
+	}
+
+	// Their reminders stay unclaimed for when they are back
+	var unclaimed int64
+	initializers.DB.Model(&models.Todo{}).Where("reminded_at IS NULL").Count(&unclaimed)
+	if unclaimed != 2 {
+		t.Fatalf("%d reminders unclaimed, want bob's and carol's", unclaimed)
+	}
+}
@@ -0,0 +1,110 @@
+package routes
+
+import (
+	"github.com/Waris-Shaik/todo-backend/controllers"
+	"github.com/Waris-Shaik/todo-backend/middlewares"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+// SetupRouter builds the router with every API route, serving data from
+// repos.
+func SetupRouter(repos repositories.Repositories) *gin.Engine {
+
+	// router
+	router := gin.Default()
+	router.Use(middlewares.UseRepositories(repos))
+
+	// Scopes a personal access token needs for each route
+	readTodos := middlewares.RequireScope(models.ScopeTodosRead)
+	writeTodos := middlewares.RequireScope(models.ScopeTodosWrite)
+	admin := middlewares.RequireScope(models.ScopeAdmin)
+
+	// Routes that manage credentials need a login session
+	loginOnly := middlewares.RequireSession
+
+	// Roles allowed on the admin routes
+	staffOnly := middlewares.RequireRole(models.RoleAdmin, models.RoleSupport)
+	adminOnly := middlewares.RequireRole(models.RoleAdmin)
+
+	// Probes
+	router.GET("/healthz", controllers.Healthz)
+	router.GET("/readyz", controllers.Readyz)
+
+	// routes
+	router.GET("/.well-known/jwks.json", controllers.GetJWKS)
+	router.POST("/api/v1/users/signup", controllers.SignUp)
+	router.POST("/api/v1/users/login", controllers.Login)
+	router.POST("/api/v1/users/login/2fa", controllers.LoginTwoFactor)
+	router.GET("/api/v1/auth/oidc", controllers.GetOIDCProviders)
+	router.GET("/api/v1/auth/oidc/:provider/login", controllers.OIDCLogin)
+	router.GET("/api/v1/auth/oidc/:provider/callback", controllers.OIDCCallback)
+	router.GET("/api/v1/auth/oidc/:provider/link", middlewares.IsAuthenticated, loginOnly, controllers.LinkIdentity)
+	router.POST("/api/v1/users/refresh", controllers.Refresh)
+	router.GET("/api/v1/users/logout", controllers.Logout)
+	router.POST("/api/v1/users/password/forgot", controllers.ForgotPassword)
+	router.POST("/api/v1/users/password/reset", controllers.ResetPassword)
+	router.POST("/api/v1/users/verify", controllers.VerifyEmail)
+	router.POST("/api/v1/users/verify/resend", middlewares.IsAuthenticated, admin, controllers.ResendVerification)
+	router.GET("/api/v1/users/me", middlewares.IsAuthenticated, controllers.Me)
+	router.GET("/api/v1/users/me/export", middlewares.IsAuthenticated, admin, controllers.ExportAccount)
+	router.DELETE("/api/v1/users/me", middlewares.IsAuthenticated, loginOnly, controllers.DeleteAccount)
+	router.GET("/api/v1/users/all", middlewares.IsAuthenticated, admin, adminOnly, controllers.GetUsers)
+	router.PATCH("/api/v1/users/updatemyprofile", middlewares.IsAuthenticated, loginOnly, controllers.UpdateUser)
+	router.GET("/api/v1/users/sessions", middlewares.IsAuthenticated, loginOnly, controllers.GetSessions)
+	router.GET("/api/v1/users/login-attempts", middlewares.IsAuthenticated, admin, controllers.GetLoginAttempts)
+	router.DELETE("/api/v1/users/sessions", middlewares.IsAuthenticated, loginOnly, controllers.RevokeOtherSessions)
+	router.DELETE("/api/v1/users/sessions/:id", middlewares.IsAuthenticated, loginOnly, controllers.RevokeSession)
+	router.GET("/api/v1/users/tokens", middlewares.IsAuthenticated, loginOnly, controllers.GetAccessTokens)
+	router.POST("/api/v1/users/tokens", middlewares.IsAuthenticated, loginOnly, controllers.CreateAccessToken)
+	router.DELETE("/api/v1/users/tokens/:id", middlewares.IsAuthenticated, loginOnly, controllers.DeleteAccessToken)
+	router.GET("/api/v1/users/identities", middlewares.IsAuthenticated, loginOnly, controllers.GetIdentities)
+	router.DELETE("/api/v1/users/identities/:id", middlewares.IsAuthenticated, loginOnly, controllers.DeleteIdentity)
+	router.POST("/api/v1/users/password", middlewares.IsAuthenticated, loginOnly, controllers.SetPassword)
+	router.DELETE("/api/v1/users/password", middlewares.IsAuthenticated, loginOnly, controllers.RemovePassword)
+	router.POST("/api/v1/users/2fa/enroll", middlewares.IsAuthenticated, loginOnly, controllers.EnrollTwoFactor)
+	router.POST("/api/v1/users/2fa/confirm", middlewares.IsAuthenticated, loginOnly, controllers.ConfirmTwoFactor)
+	router.POST("/api/v1/users/2fa/disable", middlewares.IsAuthenticated, loginOnly, controllers.DisableTwoFactor)
+	router.POST("/api/v1/users/2fa/recovery-codes", middlewares.IsAuthenticated, loginOnly, controllers.RegenerateRecoveryCodes)
+	router.POST("/api/v1/todos/new", middlewares.IsAuthenticated, writeTodos, controllers.CreateTodo)
+	router.GET("/api/v1/todos/my", middlewares.IsAuthenticated, readTodos, controllers.GetTodos)
+	router.GET("/api/v1/todos/shared", middlewares.IsAuthenticated, readTodos, controllers.GetSharedTodos)
+	router.GET("/api/v1/todos/overdue", middlewares.IsAuthenticated, readTodos, controllers.GetOverdueTodos)
+	router.GET("/api/v1/todos/upcoming", middlewares.IsAuthenticated, readTodos, controllers.GetUpcomingTodos)
+	router.GET("/api/v1/todos/trash", middlewares.IsAuthenticated, readTodos, controllers.GetTrash)
+	router.GET("/api/v1/todos/:id", middlewares.IsAuthenticated, readTodos, controllers.GetSingleTodo)
+	router.PATCH("/api/v1/todos/:id", middlewares.IsAuthenticated, writeTodos, controllers.UpdateTodo)
+	router.PUT("/api/v1/todos/:id", middlewares.IsAuthenticated, writeTodos, controllers.EditTodo)
+	router.DELETE("/api/v1/todos/:id", middlewares.IsAuthenticated, writeTodos, controllers.DeleteTodo)
+	router.POST("/api/v1/todos/:id/restore", middlewares.IsAuthenticated, writeTodos, controllers.RestoreTodo)
+	router.GET("/api/v1/todos/:id/shares", middlewares.IsAuthenticated, readTodos, controllers.GetTodoShares)
+	router.POST("/api/v1/todos/:id/shares", middlewares.IsAuthenticated, writeTodos, controllers.ShareTodo)
+	router.DELETE("/api/v1/todos/:id/shares/:shareId", middlewares.IsAuthenticated, writeTodos, controllers.DeleteTodoShare)
+	router.POST("/api/v1/todos/:id/subtasks", middlewares.IsAuthenticated, writeTodos, controllers.CreateSubtask)
+	router.PUT("/api/v1/todos/:id/subtasks/order", middlewares.IsAuthenticated, writeTodos, controllers.ReorderSubtasks)
+	router.GET("/api/v1/todos/:id/occurrences", middlewares.IsAuthenticated, readTodos, controllers.PreviewOccurrences)
+	router.POST("/api/v1/todos/:id/occurrences/skip", middlewares.IsAuthenticated, writeTodos, controllers.SkipOccurrence)
+	router.DELETE("/api/v1/todos/:id/recurrence", middlewares.IsAuthenticated, writeTodos, controllers.StopRecurrence)
+	router.POST("/api/v1/todos/:id/tags/:tagId", middlewares.IsAuthenticated, writeTodos, controllers.AttachTag)
+	router.DELETE("/api/v1/todos/:id/tags/:tagId", middlewares.IsAuthenticated, writeTodos, controllers.DetachTag)
+	router.GET("/api/v1/tags", middlewares.IsAuthenticated, readTodos, controllers.GetTags)
+	router.POST("/api/v1/tags", middlewares.IsAuthenticated, writeTodos, controllers.CreateTag)
+	router.PUT("/api/v1/tags/:id", middlewares.IsAuthenticated, writeTodos, controllers.UpdateTag)
+	router.DELETE("/api/v1/tags/:id", middlewares.IsAuthenticated, writeTodos, controllers.DeleteTag)
+	router.GET("/api/v1/projects", middlewares.IsAuthenticated, readTodos, controllers.GetProjects)
+	router.POST("/api/v1/projects", middlewares.IsAuthenticated, writeTodos, controllers.CreateProject)
+	router.PUT("/api/v1/projects/order", middlewares.IsAuthenticated, writeTodos, controllers.ReorderProjects)
+	router.GET("/api/v1/projects/:id", middlewares.IsAuthenticated, readTodos, controllers.GetProject)
+	router.PUT("/api/v1/projects/:id", middlewares.IsAuthenticated, writeTodos, controllers.UpdateProject)
+	router.DELETE("/api/v1/projects/:id", middlewares.IsAuthenticated, writeTodos, controllers.DeleteProject)
+	router.GET("/api/v1/admin/users", middlewares.IsAuthenticated, admin, staffOnly, controllers.AdminGetUsers)
+	router.GET("/api/v1/admin/users/:id", middlewares.IsAuthenticated, admin, staffOnly, controllers.AdminGetUser)
+	router.GET("/api/v1/admin/users/:id/login-attempts", middlewares.IsAuthenticated, admin, staffOnly, controllers.AdminGetLoginAttempts)
+	router.POST("/api/v1/admin/users/:id/disable", middlewares.IsAuthenticated, admin, adminOnly, controllers.AdminDisableUser)
+	router.POST("/api/v1/admin/users/:id/enable", middlewares.IsAuthenticated, admin, adminOnly, controllers.AdminEnableUser)
+	router.PUT("/api/v1/admin/users/:id/role", middlewares.IsAuthenticated, admin, adminOnly, controllers.AdminSetUserRole)
+	router.POST("/api/v1/admin/users/:id/password", middlewares.IsAuthenticated, admin, adminOnly, controllers.AdminResetPassword)
+
+	return router
+}
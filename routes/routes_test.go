@@ -0,0 +1,313 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/mailers"
+	"github.com/Waris-Shaik/todo-backend/migrations"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+	"github.com/Waris-Shaik/todo-backend/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const testPassword = "correct horse battery"
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	gin.DefaultWriter = io.Discard
+	log.SetOutput(io.Discard)
+
+	// Cheap keys and hashes keep the suite fast
+	initializers.Config.Auth.SigningAlgorithm = "EdDSA"
+	initializers.Config.Passwords.Argon2Memory = 64
+	initializers.Config.Passwords.Argon2Iterations = 1
+	initializers.Config.Passwords.Argon2Parallelism = 1
+
+	// Mailed tokens are read back from the message, so APP_URL stays unset
+
+	os.Exit(m.Run())
+}
+
+// testServer is the API running against a fresh in-memory database.
+type testServer struct {
+	t      *testing.T
+	server *httptest.Server
+	mail   *mailbox
+}
+
+// newTestServer starts the API on its own SQLite database kept in memory,
+// with users and todos stored through the GORM repositories.
+func newTestServer(t *testing.T) *testServer {
+	t.Helper()
+
+	dsn := "file:" + regexp.MustCompile(`\W`).ReplaceAllString(t.Name(), "_") + "?mode=memory&cache=shared&_pragma=foreign_keys(1)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+
+	previousDB, previousMailer := initializers.DB, initializers.Mailer
+	mail := &mailbox{}
+	initializers.DB = db
+	initializers.Mailer = mail
+	if _, err := migrations.Up(db); err != nil {
+		t.Fatalf("migrate database: %v", err)
+	}
+	if err := utils.RotateSigningKeys(); err != nil {
+		t.Fatalf("create signing keys: %v", err)
+	}
+
+	server := httptest.NewServer(SetupRouter(repositories.New(db)))
+
+	t.Cleanup(func() {
+		server.Close()
+		sqlDB.Close()
+		initializers.DB, initializers.Mailer = previousDB, previousMailer
+	})
+	return &testServer{t: t, server: server, mail: mail}
+}
+
+// client returns a client with its own cookie jar, like a browser.
+func (ts *testServer) client() *client {
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		ts.t.Fatal(err)
+	}
+	return &client{ts: ts, http: &http.Client{Jar: jar}}
+}
+
+// signUp creates an account with a verified email and returns a client
+// logged in to it.
+func (ts *testServer) signUp(username string) *client {
+	ts.t.Helper()
+
+	c := ts.client()
+	email := username + "@example.com"
+	res := c.post("/api/v1/users/signup", map[string]interface{}{
+		"name":     strings.ToUpper(username[:1]) + username[1:],
+		"username": username,
+		"email":    email,
+		"password": testPassword,
+	})
+	res.expect(http.StatusCreated)
+
+	token := ts.mail.token(email)
+	c.post("/api/v1/users/verify", map[string]interface{}{"token": token}).expect(http.StatusOK)
+	return c
+}
+
+// client sends requests to the test server.
+type client struct {
+	ts     *testServer
+	http   *http.Client
+	bearer string // Sent as an Authorization header when set
+}
+
+func (c *client) get(path string) *response {
+	return c.do(http.MethodGet, path, nil)
+}
+
+func (c *client) post(path string, body interface{}) *response {
+	return c.do(http.MethodPost, path, body)
+}
+
+func (c *client) put(path string, body interface{}) *response {
+	return c.do(http.MethodPut, path, body)
+}
+
+func (c *client) patch(path string, body interface{}) *response {
+	return c.do(http.MethodPatch, path, body)
+}
+
+func (c *client) delete(path string) *response {
+	return c.do(http.MethodDelete, path, nil)
+}
+
+// do sends a request with body encoded as JSON, or as is when it is a
+// string.
+func (c *client) do(method, path string, body interface{}) *response {
+	c.ts.t.Helper()
+
+	var reader io.Reader
+	switch body := body.(type) {
+	case nil:
+	case string:
+		reader = strings.NewReader(body)
+	default:
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			c.ts.t.Fatal(err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequest(method, c.ts.server.URL+path, reader)
+	if err != nil {
+		c.ts.t.Fatal(err)
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+c.bearer)
+	}
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		c.ts.t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer res.Body.Close()
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		c.ts.t.Fatal(err)
+	}
+
+	decoded := map[string]interface{}{}
+	json.Unmarshal(raw, &decoded)
+	return &response{t: c.ts.t, request: method + " " + path, Response: res, raw: raw, body: decoded}
+}
+
+// cookie returns the value the jar would send for name on path.
+func (c *client) cookie(path string, name string) string {
+	u, _ := url.Parse(c.ts.server.URL + path)
+	for _, cookie := range c.http.Jar.Cookies(u) {
+		if cookie.Name == name {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// response is a decoded JSON response.
+type response struct {
+	*http.Response
+	t       *testing.T
+	request string
+	raw     []byte
+	body    map[string]interface{}
+}
+
+// expect fails the test unless the response has the status.
+func (res *response) expect(status int) *response {
+	res.t.Helper()
+	if res.StatusCode != status {
+		res.t.Fatalf("%s: got status %d, want %d: %s", res.request, res.StatusCode, status, res.raw)
+	}
+	return res
+}
+
+// expectMessage fails the test unless the response has the status and a
+// message containing text.
+func (res *response) expectMessage(status int, text string) *response {
+	res.t.Helper()
+	res.expect(status)
+	if message, _ := res.body["message"].(string); !strings.Contains(message, text) {
+		res.t.Fatalf("%s: got message %q, want it to contain %q", res.request, message, text)
+	}
+	return res
+}
+
+// object returns the JSON object under key.
+func (res *response) object(key string) map[string]interface{} {
+	res.t.Helper()
+	object, ok := res.body[key].(map[string]interface{})
+	if !ok {
+		res.t.Fatalf("%s: %q is not an object: %s", res.request, key, res.raw)
+	}
+	return object
+}
+
+// list returns the JSON array under key.
+func (res *response) list(key string) []interface{} {
+	res.t.Helper()
+	list, ok := res.body[key].([]interface{})
+	if !ok {
+		res.t.Fatalf("%s: %q is not an array: %s", res.request, key, res.raw)
+	}
+	return list
+}
+
+// cookie returns the cookie the response sets.
+func (res *response) cookie(name string) *http.Cookie {
+	for _, cookie := range res.Cookies() {
+		if cookie.Name == name {
+			return cookie
+		}
+	}
+	return nil
+}
+
+// mailbox keeps the emails the API sends.
+type mailbox struct {
+	mu       sync.Mutex
+	messages []mailers.Message
+}
+
+func (box *mailbox) Send(ctx context.Context, message mailers.Message) error {
+	box.mu.Lock()
+	defer box.mu.Unlock()
+	box.messages = append(box.messages, message)
+	return nil
+}
+
+var mailedToken = regexp.MustCompile(`Use this token: (\S+)`)
+
+// token returns the token in the last email sent to address.
+func (box *mailbox) token(address string) string {
+	box.mu.Lock()
+	defer box.mu.Unlock()
+	for i := len(box.messages) - 1; i >= 0; i-- {
+		if box.messages[i].To == address {
+			if match := mailedToken.FindStringSubmatch(box.messages[i].Body); match != nil {
+				return match[1]
+			}
+		}
+	}
+	return ""
+}
+
+// awaitToken waits for an email to address sent in the background, and
+// returns its token.
+func (box *mailbox) awaitToken(address string) string {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if token := box.token(address); token != "" {
+			return token
+		}
+	}
+	return ""
+}
+
+// id returns the "ID" of a JSON object as a path segment.
+func id(object map[string]interface{}) string {
+	if value, ok := object["ID"].(float64); ok {
+		return jsonNumber(value)
+	}
+	return jsonNumber(object["_id"].(float64))
+}
+
+func jsonNumber(value float64) string {
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
@@ -0,0 +1,424 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/utils"
+)
+
+func TestTodoRoutesRequireLogin(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.client()
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/todos/new"},
+		{http.MethodGet, "/api/v1/todos/my"},
+		{http.MethodGet, "/api/v1/todos/shared"},
+		{http.MethodGet, "/api/v1/todos/overdue"},
+		{http.MethodGet, "/api/v1/todos/upcoming"},
+		{http.MethodGet, "/api/v1/todos/trash"},
+		{http.MethodGet, "/api/v1/todos/1"},
+		{http.MethodPatch, "/api/v1/todos/1"},
+		{http.MethodPut, "/api/v1/todos/1"},
+		{http.MethodDelete, "/api/v1/todos/1"},
+		{http.MethodPost, "/api/v1/todos/1/restore"},
+		{http.MethodGet, "/api/v1/todos/1/shares"},
+		{http.MethodPost, "/api/v1/todos/1/shares"},
+		{http.MethodDelete, "/api/v1/todos/1/shares/1"},
+		{http.MethodPost, "/api/v1/todos/1/subtasks"},
+		{http.MethodPut, "/api/v1/todos/1/subtasks/order"},
+		{http.MethodGet, "/api/v1/todos/1/occurrences"},
+		{http.MethodPost, "/api/v1/todos/1/occurrences/skip"},
+		{http.MethodDelete, "/api/v1/todos/1/recurrence"},
+		{http.MethodPost, "/api/v1/todos/1/tags/1"},
+		{http.MethodDelete, "/api/v1/todos/1/tags/1"},
+	} {
+		c.do(route.method, route.path, nil).expectMessage(http.StatusUnauthorized, "please login")
+	}
+}
+
+func TestCreateTodo(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.signUp("alice")
+	bob := ts.signUp("bob")
+
+	c.post("/api/v1/todos/new", `{"title":`).expect(http.StatusBadRequest)
+	c.post("/api/v1/todos/new", map[string]interface{}{"title": "Groceries"}).
+		expectMessage(http.StatusBadRequest, "please fill all required fields")
+	c.post("/api/v1/todos/new", map[string]interface{}{"title": "Groceries", "description": "Milk", "priority": "whenever"}).
+		expect(http.StatusBadRequest)
+	c.post("/api/v1/todos/new", map[string]interface{}{"title": "Groceries", "description": "Milk", "due_at": "tomorrow"}).
+		expectMessage(http.StatusBadRequest, "due_at")
+	c.post("/api/v1/todos/new", map[string]interface{}{"title": "Groceries", "description": "Milk", "recurrence": "FREQ=WEEKLY"}).
+		expectMessage(http.StatusBadRequest, "recurring todos need a due_at")
+
+	// Projects have to belong to the user
+	project := bob.post("/api/v1/projects", map[string]interface{}{"name": "Bob's"}).expect(http.StatusCreated).object("project")
+	c.post("/api/v1/todos/new", map[string]interface{}{"title": "Groceries", "description": "Milk", "project_id": project["ID"]}).
+		expect(http.StatusBadRequest)
+
+	todo := c.post("/api/v1/todos/new", map[string]interface{}{"title": "Groceries", "description": "Milk", "user_id": 2}).
+		expectMessage(http.StatusCreated, "Todo Successfully Created").object("todo")
+	if todo["priority"] != "medium" || todo["completed"] != false {
+		t.Fatalf("new todo = %v, want an open todo with medium priority", todo)
+	}
+	if owner := todo["user"].(map[string]interface{}); owner["username"] != "alice" {
+		t.Fatalf("todo belongs to %v, want alice", owner["username"])
+	}
+}
+
+// TestTodoLifecycle runs the todo routes from creating to toggling,
+// trashing, restoring and deleting for good.
+func TestTodoLifecycle(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.signUp("alice")
+	bob := ts.signUp("bob")
+
+	now := time.Now().UTC()
+	first := c.post("/api/v1/todos/new", map[string]interface{}{"title": "Alpha", "description": "first", "priority": "high"}).
+		expect(http.StatusCreated).object("todo")
+	c.post("/api/v1/todos/new", map[string]interface{}{"title": "Bravo", "description": "late", "due_at": now.Add(-time.Hour).Format(time.RFC3339)}).
+		expect(http.StatusCreated)
+	c.post("/api/v1/todos/new", map[string]interface{}{"title": "Charlie", "description": "soon", "due_at": now.Add(time.Hour).Format(time.RFC3339)}).
+		expect(http.StatusCreated)
+	bob.post("/api/v1/todos/new", map[string]interface{}{"title": "Bob's", "description": "private"}).expect(http.StatusCreated)
+
+	// Listing pages through the user's own todos
+	page := c.get("/api/v1/todos/my?sort=title&limit=2").expect(http.StatusOK)
+	if todos := page.list("todos"); len(todos) != 2 || page.body["total"] != 3.0 {
+		t.Fatalf("first page has %d of %v todos, want 2 of 3", len(todos), page.body["total"])
+	}
+	cursor, _ := page.body["next_cursor"].(string)
+	page = c.get("/api/v1/todos/my?sort=title&limit=2&cursor=" + cursor).expect(http.StatusOK)
+	if todos := page.list("todos"); len(todos) != 1 || todos[0].(map[string]interface{})["title"] != "Charlie" || page.body["next_cursor"] != "" {
+		t.Fatalf("second page = %s, want only Charlie", page.raw)
+	}
+	if todos := c.get("/api/v1/todos/my?q=LATE").list("todos"); len(todos) != 1 {
+		t.Fatalf("search found %d todos, want 1", len(todos))
+	}
+	c.get("/api/v1/todos/my?sort=colour").expectMessage(http.StatusBadRequest, "sort must be one of")
+	c.get("/api/v1/todos/my?cursor=nonsense").expectMessage(http.StatusBadRequest, "invalid cursor")
+
+	if todos := c.get("/api/v1/todos/overdue").list("todos"); len(todos) != 1 || todos[0].(map[string]interface{})["title"] != "Bravo" {
+		t.Fatalf("overdue todos = %v, want Bravo", todos)
+	}
+	if todos := c.get("/api/v1/todos/upcoming?within=2h").list("todos"); len(todos) != 1 || todos[0].(map[string]interface{})["title"] != "Charlie" {
+		t.Fatalf("upcoming todos = %v, want Charlie", todos)
+	}
+	c.get("/api/v1/todos/upcoming?within=soon").expect(http.StatusBadRequest)
+
+	// Reading and editing
+	path := "/api/v1/todos/" + id(first)
+	c.get(path).expect(http.StatusOK)
+	c.get("/api/v1/todos/9999").expectMessage(http.StatusNotFound, "todo not found")
+	c.put(path, map[string]interface{}{}).expectMessage(http.StatusOK, "no changes were made")
+	c.put(path, map[string]interface{}{"due_at": "someday"}).expect(http.StatusBadRequest)
+	edited := c.put(path, map[string]interface{}{"title": "Alpha edited", "priority": "low"}).
+		expectMessage(http.StatusOK, "todo edited successfully").object("todo")
+	if edited["title"] != "Alpha edited" || edited["priority"] != "low" || edited["description"] != "first" {
+		t.Fatalf("edited todo = %v", edited)
+	}
+
+	// Subtasks are listed with their todo
+	c.post(path+"/subtasks", map[string]interface{}{"title": "Step one"}).expect(http.StatusCreated)
+	c.post(path+"/subtasks", map[string]interface{}{"title": "Step two"}).expect(http.StatusCreated)
+	if subtasks := c.get(path).object("todo")["subtasks"].([]interface{}); len(subtasks) != 2 {
+		t.Fatalf("todo has %d subtasks, want 2", len(subtasks))
+	}
+
+	// Completing sticks, and takes the subtasks along with cascade
+	if toggled := c.patch(path+"?cascade=true", nil).expect(http.StatusOK).object("todo"); toggled["completed"] != true {
+		t.Fatalf("todo is not completed after toggling: %v", toggled)
+	}
+	stored := c.get(path).object("todo")
+	if stored["completed"] != true || stored["subtasks"].([]interface{})[0].(map[string]interface{})["completed"] != true {
+		t.Fatalf("todo after toggling = %v, want it and its subtasks completed", stored)
+	}
+
+	// Completing a recurring todo creates its next occurrence
+	recurring := c.post("/api/v1/todos/new", map[string]interface{}{"title": "Daily", "description": "Again", "due_at": "2030-01-01T09:00:00Z", "recurrence": "FREQ=DAILY"}).
+		expect(http.StatusCreated).object("todo")
+	next := c.patch("/api/v1/todos/"+id(recurring), nil).expect(http.StatusOK).object("next_occurrence")
+	if c.get("/api/v1/todos/" + id(next)).object("todo")["due_at"] != "2030-01-02T09:00:00Z" {
+		t.Fatal("next occurrence is not due the day after")
+	}
+	if c.get("/api/v1/todos/" + id(recurring)).object("todo")["next_occurrence_id"] != next["ID"] {
+		t.Fatal("completed occurrence does not point to the next one")
+	}
+	c.delete("/api/v1/todos/" + id(recurring) + "?permanent=true").expect(http.StatusOK)
+	c.delete("/api/v1/todos/" + id(next) + "?permanent=true").expect(http.StatusOK)
+
+	// Other users cannot see, change or delete the todo
+	bob.get(path).expect(http.StatusNotFound)
+	bob.put(path, map[string]interface{}{"title": "Mine"}).expect(http.StatusNotFound)
+	bob.delete(path).expect(http.StatusNotFound)
+
+	// Deleting takes the subtasks along
+	c.delete(path).expectMessage(http.StatusOK, "Todo deleted successfully")
+	c.get(path).expect(http.StatusNotFound)
+	if page := c.get("/api/v1/todos/my").expect(http.StatusOK); page.body["total"] != 2.0 {
+		t.Fatalf("%v todos left after deleting, want 2", page.body["total"])
+	}
+
+	// Deleted todos wait in the trash until restored
+	bob.post(path+"/restore", nil).expect(http.StatusNotFound)
+	if trash := c.get("/api/v1/todos/trash").list("todos"); len(trash) != 1 || trash[0].(map[string]interface{})["title"] != "Alpha edited" {
+		t.Fatalf("trash = %v, want only the deleted todo", trash)
+	}
+	c.post(path+"/restore", nil).expectMessage(http.StatusOK, "todo successfully restored")
+	if subtasks := c.get(path).expect(http.StatusOK).object("todo")["subtasks"].([]interface{}); len(subtasks) != 2 {
+		t.Fatalf("restored todo has %d subtasks, want 2", len(subtasks))
+	}
+
+	// Deleting permanently skips the trash
+	bob.delete(path + "?permanent=true").expect(http.StatusNotFound)
+	c.delete(path+"?permanent=true").expectMessage(http.StatusOK, "Todo permanently deleted")
+	if trash := c.get("/api/v1/todos/trash").list("todos"); len(trash) != 0 {
+		t.Fatalf("trash = %v after deleting permanently, want it empty", trash)
+	}
+	c.post(path+"/restore", nil).expect(http.StatusNotFound)
+}
+
+func TestToggleTodo(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.signUp("alice")
+
+	todo := c.post("/api/v1/todos/new", map[string]interface{}{"title": "Laundry", "description": "Whites"}).object("todo")
+	path := "/api/v1/todos/" + id(todo)
+	subtask := c.post(path+"/subtasks", map[string]interface{}{"title": "Fold"}).expect(http.StatusCreated).object("todo")
+
+	// Without cascade only the todo is completed
+	updated := c.patch(path, nil).expectMessage(http.StatusOK, "todo successfully updated").object("todo")
+	if updated["completed"] != true {
+		t.Fatalf("todo is not completed after toggling: %v", updated)
+	}
+	if c.get("/api/v1/todos/" + id(subtask)).object("todo")["completed"] != false {
+		t.Fatal("subtask was completed without cascade")
+	}
+
+	// With cascade the subtasks follow
+	c.patch(path, nil).expect(http.StatusOK)
+	c.patch(path+"?cascade=true", nil).expect(http.StatusOK)
+	if c.get("/api/v1/todos/" + id(subtask)).object("todo")["completed"] != true {
+		t.Fatal("subtask was not completed with cascade")
+	}
+}
+
+func TestSubtasks(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.signUp("alice")
+
+	todo := c.post("/api/v1/todos/new", map[string]interface{}{"title": "Move", "description": "House", "priority": "urgent"}).object("todo")
+	path := "/api/v1/todos/" + id(todo)
+
+	c.post(path+"/subtasks", map[string]interface{}{"description": "No title"}).
+		expectMessage(http.StatusBadRequest, "subtask title is required")
+	c.post("/api/v1/todos/9999/subtasks", map[string]interface{}{"title": "Orphan"}).expect(http.StatusNotFound)
+
+	pack := c.post(path+"/subtasks", map[string]interface{}{"title": "Pack"}).expect(http.StatusCreated).object("todo")
+	load := c.post(path+"/subtasks", map[string]interface{}{"title": "Load"}).expect(http.StatusCreated).object("todo")
+	if pack["priority"] != "urgent" || pack["parent_id"] != todo["ID"] {
+		t.Fatalf("subtask = %v, want it to inherit the priority of its todo", pack)
+	}
+
+	// The order has to list every subtask once
+	c.put(path+"/subtasks/order", map[string]interface{}{"subtask_ids": []interface{}{pack["ID"]}}).
+		expectMessage(http.StatusBadRequest, "subtask_ids must list every subtask")
+	c.put(path+"/subtasks/order", map[string]interface{}{"subtask_ids": []interface{}{load["ID"], load["ID"]}}).
+		expect(http.StatusBadRequest)
+	c.put(path+"/subtasks/order", map[string]interface{}{"subtask_ids": []interface{}{load["ID"], pack["ID"]}}).
+		expectMessage(http.StatusOK, "subtasks successfully reordered")
+
+	subtasks := c.get(path).object("todo")["subtasks"].([]interface{})
+	if len(subtasks) != 2 || subtasks[0].(map[string]interface{})["title"] != "Load" {
+		t.Fatalf("subtasks = %v, want Load first", subtasks)
+	}
+
+	// Subtasks are not listed as todos of their own
+	if todos := c.get("/api/v1/todos/my").list("todos"); len(todos) != 1 {
+		t.Fatalf("listed %d todos, want only the parent", len(todos))
+	}
+
+	// Subtasks nest up to MaxSubtaskDepth levels below the todo
+	parent := pack
+	for level := 2; level <= utils.MaxSubtaskDepth; level++ {
+		parent = c.post("/api/v1/todos/"+id(parent)+"/subtasks", map[string]interface{}{"title": "Level " + strconv.Itoa(level)}).
+			expect(http.StatusCreated).object("todo")
+	}
+	c.post("/api/v1/todos/"+id(parent)+"/subtasks", map[string]interface{}{"title": "Too deep"}).
+		expectMessage(http.StatusBadRequest, "subtasks cannot be nested any deeper")
+}
+
+func TestRecurringTodos(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.signUp("alice")
+
+	plain := c.post("/api/v1/todos/new", map[string]interface{}{"title": "Once", "description": "Only once"}).object("todo")
+	c.get("/api/v1/todos/"+id(plain)+"/occurrences").expectMessage(http.StatusBadRequest, "todo is not recurring")
+	c.post("/api/v1/todos/"+id(plain)+"/occurrences/skip", nil).
+		expectMessage(http.StatusBadRequest, "only open recurring todos can be skipped")
+
+	c.post("/api/v1/todos/new", map[string]interface{}{"title": "Water", "description": "Plants", "due_at": "2030-01-01T09:00:00Z", "recurrence": "FREQ=SOMETIMES"}).
+		expectMessage(http.StatusBadRequest, "recurrence")
+	todo := c.post("/api/v1/todos/new", map[string]interface{}{"title": "Water", "description": "Plants", "due_at": "2030-01-01T09:00:00Z", "recurrence": "FREQ=DAILY"}).
+		expect(http.StatusCreated).object("todo")
+	path := "/api/v1/todos/" + id(todo)
+
+	c.get(path+"/occurrences?count=0").expectMessage(http.StatusBadRequest, "count must be between 1 and 50")
+	if occurrences := c.get(path + "/occurrences?count=3").expect(http.StatusOK).list("occurrences"); len(occurrences) != 3 {
+		t.Fatalf("previewed %d occurrences, want 3", len(occurrences))
+	}
+
+	skipped := c.post(path+"/occurrences/skip", nil).expectMessage(http.StatusOK, "occurrence successfully skipped").object("todo")
+	if skipped["due_at"] != "2030-01-02T09:00:00Z" {
+		t.Fatalf("skipped to %v, want the next day", skipped["due_at"])
+	}
+
+	// Completing an occurrence creates the next one
+	res := c.patch(path, nil).expect(http.StatusOK)
+	next := res.object("next_occurrence")
+	if next["due_at"] != "2030-01-03T09:00:00Z" {
+		t.Fatalf("next occurrence is due %v, want the day after", next["due_at"])
+	}
+
+	// Once stopped, the series ends with the todo
+	nextPath := "/api/v1/todos/" + id(next)
+	c.delete(nextPath+"/recurrence").expectMessage(http.StatusOK, "recurrence successfully stopped")
+	if res := c.patch(nextPath, nil).expect(http.StatusOK); res.body["next_occurrence"] != nil {
+		t.Fatal("completing a stopped series created another occurrence")
+	}
+}
+
+func TestTrash(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.signUp("alice")
+
+	todo := c.post("/api/v1/todos/new", map[string]interface{}{"title": "Old", "description": "Stuff"}).object("todo")
+	path := "/api/v1/todos/" + id(todo)
+	subtask := c.post(path+"/subtasks", map[string]interface{}{"title": "Older"}).object("todo")
+
+	c.post(path+"/restore", nil).expectMessage(http.StatusNotFound, "todo not found in trash")
+	c.delete(path).expect(http.StatusOK)
+
+	// Subtasks deleted with their todo are not listed on their own
+	if todos := c.get("/api/v1/todos/trash").list("todos"); len(todos) != 1 || todos[0].(map[string]interface{})["title"] != "Old" {
+		t.Fatalf("trash = %v, want only the todo", todos)
+	}
+
+	c.post(path+"/restore", nil).expectMessage(http.StatusOK, "todo successfully restored")
+	if subtasks := c.get(path).expect(http.StatusOK).object("todo")["subtasks"].([]interface{}); len(subtasks) != 1 {
+		t.Fatal("subtask was not restored along with its todo")
+	}
+
+	// A subtask cannot come back without its todo
+	c.delete(path).expect(http.StatusOK)
+	c.post("/api/v1/todos/"+id(subtask)+"/restore", nil).expectMessage(http.StatusConflict, "restore the parent todo first")
+
+	c.delete(path+"?permanent=true").expectMessage(http.StatusOK, "Todo permanently deleted")
+	if todos := c.get("/api/v1/todos/trash").list("todos"); len(todos) != 0 {
+		t.Fatalf("trash still has %d todos after deleting permanently", len(todos))
+	}
+	c.post(path+"/restore", nil).expect(http.StatusNotFound)
+}
+
+func TestSharedTodos(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.signUp("alice")
+	bob := ts.signUp("bob")
+
+	todo := c.post("/api/v1/todos/new", map[string]interface{}{"title": "Trip", "description": "Plan"}).object("todo")
+	path := "/api/v1/todos/" + id(todo)
+
+	// Only verified owners can share, with existing users and known roles
+	unverified := ts.client()
+	unverified.post("/api/v1/users/signup", map[string]interface{}{
+		"name": "Carol", "username": "carol", "email": "carol@example.com", "password": testPassword,
+	}).expect(http.StatusCreated)
+	carols := unverified.post("/api/v1/todos/new", map[string]interface{}{"title": "Carol's", "description": "Todo"}).object("todo")
+	unverified.post("/api/v1/todos/"+id(carols)+"/shares", map[string]interface{}{"username": "bob", "role": "viewer"}).
+		expectMessage(http.StatusForbidden, "please verify your email")
+
+	c.post(path+"/shares", map[string]interface{}{"username": "bob"}).expect(http.StatusBadRequest)
+	c.post(path+"/shares", map[string]interface{}{"username": "bob", "role": "admin"}).
+		expectMessage(http.StatusBadRequest, "role must be one of")
+	c.post(path+"/shares", map[string]interface{}{"username": "nobody", "role": "viewer"}).expect(http.StatusNotFound)
+	c.post(path+"/shares", map[string]interface{}{"username": "alice", "role": "viewer"}).
+		expectMessage(http.StatusBadRequest, "cannot be shared with its creator")
+
+	// Viewers can read but not change
+	share := c.post(path+"/shares", map[string]interface{}{"email": "bob@example.com", "role": "viewer"}).
+		expectMessage(http.StatusCreated, "todo successfully shared").object("share")
+	if role := bob.get(path).expect(http.StatusOK).body["role"]; role != "viewer" {
+		t.Fatalf("bob's role = %v, want viewer", role)
+	}
+	if todos := bob.get("/api/v1/todos/shared").list("todos"); len(todos) != 1 {
+		t.Fatalf("bob has %d shared todos, want 1", len(todos))
+	}
+	bob.put(path, map[string]interface{}{"title": "Changed"}).expect(http.StatusForbidden)
+	bob.patch(path, nil).expect(http.StatusForbidden)
+	bob.post(path+"/subtasks", map[string]interface{}{"title": "Sneaky"}).expect(http.StatusForbidden)
+	bob.get(path + "/shares").expect(http.StatusForbidden)
+
+	// Editors can change but not delete or share
+	c.post(path+"/shares", map[string]interface{}{"username": "bob", "role": "editor"}).expect(http.StatusCreated)
+	bob.put(path, map[string]interface{}{"title": "Trip to Rome"}).expect(http.StatusOK)
+	bob.delete(path).expect(http.StatusForbidden)
+	bob.post(path+"/shares", map[string]interface{}{"username": "carol", "role": "viewer"}).expect(http.StatusForbidden)
+
+	if shares := c.get(path + "/shares").expect(http.StatusOK).list("shares"); len(shares) != 1 || shares[0].(map[string]interface{})["role"] != "editor" {
+		t.Fatalf("shares = %v, want bob as editor", shares)
+	}
+
+	// Revoking the share takes the todo away
+	c.delete(path + "/shares/9999").expect(http.StatusNotFound)
+	c.delete(path+"/shares/"+id(share)).expectMessage(http.StatusOK, "share successfully revoked")
+	bob.get(path).expect(http.StatusNotFound)
+}
+
+func TestTodoTags(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.signUp("alice")
+	bob := ts.signUp("bob")
+
+	todo := c.post("/api/v1/todos/new", map[string]interface{}{"title": "Tax", "description": "Return"}).object("todo")
+	c.post("/api/v1/todos/new", map[string]interface{}{"title": "Other", "description": "Todo"}).expect(http.StatusCreated)
+	path := "/api/v1/todos/" + id(todo)
+
+	tag := c.post("/api/v1/tags", map[string]interface{}{"name": "money"}).expect(http.StatusCreated).object("tag")
+	bobsTag := bob.post("/api/v1/tags", map[string]interface{}{"name": "bob"}).expect(http.StatusCreated).object("tag")
+
+	c.post(path+"/tags/"+id(bobsTag), nil).expectMessage(http.StatusNotFound, "tag not found")
+	bob.post(path+"/tags/"+id(bobsTag), nil).expect(http.StatusNotFound)
+
+	c.post(path+"/tags/"+id(tag), nil).expectMessage(http.StatusOK, "todo tags successfully updated")
+	if todos := c.get("/api/v1/todos/my?tag=money").list("todos"); len(todos) != 1 || todos[0].(map[string]interface{})["title"] != "Tax" {
+		t.Fatalf("todos tagged money = %v, want Tax", todos)
+	}
+
+	c.delete(path + "/tags/" + id(tag)).expect(http.StatusOK)
+	if todos := c.get("/api/v1/todos/my?tag=money").list("todos"); len(todos) != 0 {
+		t.Fatalf("%d todos still tagged money after detaching", len(todos))
+	}
+}
+
+func TestAccessTokenScopes(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.signUp("alice")
+
+	token := c.post("/api/v1/users/tokens", map[string]interface{}{"name": "cli", "scopes": []string{"todos:read"}}).
+		expect(http.StatusCreated).body["token"].(string)
+
+	// The token works without cookies, only for what its scopes allow
+	cli := ts.client()
+	cli.bearer = token
+	cli.get("/api/v1/todos/my").expect(http.StatusOK)
+	cli.post("/api/v1/todos/new", map[string]interface{}{"title": "From the CLI", "description": "Nope"}).
+		expectMessage(http.StatusForbidden, "todos:write")
+	cli.get("/api/v1/users/sessions").expect(http.StatusForbidden)
+
+	cli.bearer = token + "x"
+	cli.get("/api/v1/todos/my").expect(http.StatusUnauthorized)
+}
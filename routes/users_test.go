@@ -0,0 +1,357 @@
+package routes
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/utils"
+)
+
+func TestSignUp(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.client()
+
+	// Every field is required and the password has to follow the policy
+	c.post("/api/v1/users/signup", map[string]interface{}{
+		"name": "Alice", "email": "alice@example.com", "password": testPassword,
+	}).expectMessage(http.StatusBadRequest, "please fill all required fields")
+	c.post("/api/v1/users/signup", map[string]interface{}{
+		"name": "Alice", "username": "alice", "email": "alice@example.com", "password": "short",
+	}).expectMessage(http.StatusBadRequest, "at least")
+	c.post("/api/v1/users/signup", map[string]interface{}{
+		"name": "Alice", "username": "alice", "email": "alice.example.com", "password": testPassword,
+	}).expectMessage(http.StatusBadRequest, "valid email address")
+	c.post("/api/v1/users/signup", map[string]interface{}{
+		"name": "Alice", "username": "alice", "email": "alice@example.com", "password": testPassword, "time_zone": "Mars/Olympus",
+	}).expect(http.StatusBadRequest)
+	c.post("/api/v1/users/signup", `{"name":`).expect(http.StatusBadRequest)
+
+	res := c.post("/api/v1/users/signup", map[string]interface{}{
+		"name": "Alice", "username": "alice", "email": "alice@example.com", "password": testPassword,
+		"role": "admin", "email_verified_at": "2024-01-01T00:00:00Z", "two_factor_enabled_at": "2024-01-01T00:00:00Z",
+	}).expect(http.StatusCreated)
+	if role := res.object("user")["role"]; role != "user" {
+		t.Errorf("signed up with role %v, want user", role)
+	}
+	if user := res.object("user"); user["email_verified_at"] != nil || user["two_factor_enabled_at"] != nil {
+		t.Errorf("signed up with verification or two-factor state set: %v", user)
+	}
+	if _, ok := res.object("user")["password"]; ok {
+		t.Error("sign up response includes the password hash")
+	}
+
+	// The session cookies are HTTP only, the refresh token is only sent to
+	// the user routes
+	token, refresh := res.cookie("token"), res.cookie("refresh_token")
+	if token == nil || token.Value == "" || !token.HttpOnly {
+		t.Fatalf("token cookie = %+v, want an HTTP only cookie", token)
+	}
+	if refresh == nil || refresh.Path != "/api/v1/users" || !refresh.HttpOnly {
+		t.Fatalf("refresh_token cookie = %+v, want an HTTP only cookie on /api/v1/users", refresh)
+	}
+	if c.cookie("/api/v1/todos/my", "refresh_token") != "" {
+		t.Error("refresh_token cookie is sent outside of /api/v1/users")
+	}
+	c.get("/api/v1/users/me").expectMessage(http.StatusOK, "Welcome Back Alice")
+
+	// Emails are unique
+	ts.client().post("/api/v1/users/signup", map[string]interface{}{
+		"name": "Other", "username": "other", "email": "alice@example.com", "password": testPassword,
+	}).expectMessage(http.StatusBadRequest, "user already exists")
+
+	// The emailed token verifies the address
+	me := c.get("/api/v1/users/me").object("user")
+	if me["email_verified_at"] != nil {
+		t.Fatal("email is verified before using the token")
+	}
+	c.post("/api/v1/users/verify", map[string]interface{}{"token": "wrong"}).expect(http.StatusBadRequest)
+	c.post("/api/v1/users/verify", map[string]interface{}{"token": ts.mail.token("alice@example.com")}).expect(http.StatusOK)
+	if me := c.get("/api/v1/users/me").object("user"); me["email_verified_at"] == nil {
+		t.Fatal("email is not verified after using the token")
+	}
+}
+
+func TestLogin(t *testing.T) {
+	ts := newTestServer(t)
+	ts.signUp("alice")
+	c := ts.client()
+
+	c.post("/api/v1/users/login", map[string]interface{}{"email": "alice@example.com"}).
+		expectMessage(http.StatusBadRequest, "please fill all required fileds")
+
+	// Unknown emails and wrong passwords get the same answer
+	c.post("/api/v1/users/login", map[string]interface{}{"email": "nobody@example.com", "password": testPassword}).
+		expectMessage(http.StatusUnauthorized, "invalid email or password")
+	res := c.post("/api/v1/users/login", map[string]interface{}{"email": "alice@example.com", "password": "wrong password"}).
+		expectMessage(http.StatusUnauthorized, "invalid email or password")
+	if res.cookie("token") != nil {
+		t.Fatal("a failed login set the token cookie")
+	}
+	c.get("/api/v1/users/me").expectMessage(http.StatusUnauthorized, "please login")
+
+	res = c.post("/api/v1/users/login", map[string]interface{}{"email": "alice@example.com", "password": testPassword}).
+		expectMessage(http.StatusOK, "Welcome back Alice")
+	if res.cookie("token") == nil || res.cookie("refresh_token") == nil {
+		t.Fatal("login did not set the session cookies")
+	}
+	c.get("/api/v1/users/me").expect(http.StatusOK)
+
+	// The access token also works as a bearer token without cookies
+	bearer := ts.client()
+	bearer.bearer = res.cookie("token").Value
+	bearer.get("/api/v1/users/me").expect(http.StatusOK)
+	bearer.bearer = "not-a-token"
+	bearer.get("/api/v1/users/me").expectMessage(http.StatusUnauthorized, "invalid token")
+}
+
+func TestLoginLockout(t *testing.T) {
+	ts := newTestServer(t)
+	ts.signUp("alice")
+	c := ts.client()
+
+	for i := 0; i < initializers.Config.Auth.LoginMaxFailures; i++ {
+		c.post("/api/v1/users/login", map[string]interface{}{"email": "alice@example.com", "password": "wrong password"}).
+			expect(http.StatusUnauthorized)
+	}
+
+	// Past the limit even the right password has to wait
+	res := c.post("/api/v1/users/login", map[string]interface{}{"email": "alice@example.com", "password": testPassword}).
+		expectMessage(http.StatusTooManyRequests, "too many failed login attempts")
+	if retry := res.Header.Get("Retry-After"); retry == "" || retry == "0" {
+		t.Errorf("Retry-After = %q, want the seconds left", retry)
+	}
+}
+
+func TestForgotPassword(t *testing.T) {
+	ts := newTestServer(t)
+	ts.signUp("alice")
+	c := ts.client()
+
+	// Unknown emails get the same answer
+	c.post("/api/v1/users/password/forgot", map[string]interface{}{"email": "nobody@example.com"}).
+		expectMessage(http.StatusOK, "If an account exists")
+	c.post("/api/v1/users/password/forgot", map[string]interface{}{"email": "alice@example.com"}).
+		expectMessage(http.StatusOK, "If an account exists")
+
+	token := ts.mail.awaitToken("alice@example.com")
+	if token == "" {
+		t.Fatal("no reset email was sent")
+	}
+	c.post("/api/v1/users/password/reset", map[string]interface{}{"token": token, "password": "a new password to use"}).
+		expect(http.StatusOK)
+	c.post("/api/v1/users/login", map[string]interface{}{"email": "alice@example.com", "password": "a new password to use"}).
+		expect(http.StatusOK)
+
+	// An address can only ask so often
+	c.post("/api/v1/users/password/forgot", map[string]interface{}{"email": "nobody@example.com"}).expect(http.StatusOK)
+	c.post("/api/v1/users/password/forgot", map[string]interface{}{"email": "NOBODY@example.com"}).expect(http.StatusOK)
+	res := c.post("/api/v1/users/password/forgot", map[string]interface{}{"email": "nobody@example.com"}).
+		expectMessage(http.StatusTooManyRequests, "too many password reset requests")
+	if res.Header.Get("Retry-After") == "" {
+		t.Error("throttled response has no Retry-After")
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.signUp("alice")
+
+	ts.client().post("/api/v1/users/refresh", nil).expectMessage(http.StatusUnauthorized, "refresh token is required")
+
+	// The refresh token rotates, and reusing the old one is refused
+	oldRefresh := c.cookie("/api/v1/users", "refresh_token")
+	c.post("/api/v1/users/refresh", nil).expectMessage(http.StatusOK, "Token refreshed")
+	if c.cookie("/api/v1/users", "refresh_token") == oldRefresh {
+		t.Fatal("refresh token was not rotated")
+	}
+	c.get("/api/v1/users/me").expect(http.StatusOK)
+
+	ts.client().post("/api/v1/users/refresh", map[string]interface{}{"refresh_token": oldRefresh}).
+		expect(http.StatusUnauthorized)
+}
+
+func TestLogout(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.signUp("alice")
+	accessToken := c.cookie("/api/v1/todos/my", "token")
+
+	res := c.get("/api/v1/users/logout").expectMessage(http.StatusOK, "Successfully logged out")
+	if token := res.cookie("token"); token == nil || token.MaxAge >= 0 {
+		t.Fatalf("token cookie = %+v, want it removed", token)
+	}
+	if c.cookie("/api/v1/users", "token") != "" || c.cookie("/api/v1/users", "refresh_token") != "" {
+		t.Fatal("cookies are still sent after logging out")
+	}
+	c.get("/api/v1/users/me").expect(http.StatusUnauthorized)
+
+	// The session is revoked, so a copy of the access token stops working
+	stolen := ts.client()
+	stolen.bearer = accessToken
+	stolen.get("/api/v1/users/me").expectMessage(http.StatusUnauthorized, "session expired")
+
+	// Logging out without a session is harmless
+	ts.client().get("/api/v1/users/logout").expect(http.StatusOK)
+}
+
+func TestUpdateUser(t *testing.T) {
+	ts := newTestServer(t)
+	ts.signUp("bob")
+	c := ts.signUp("alice")
+
+	ts.client().patch("/api/v1/users/updatemyprofile", map[string]interface{}{"name": "Eve"}).
+		expect(http.StatusUnauthorized)
+
+	c.patch("/api/v1/users/updatemyprofile", map[string]interface{}{}).expectMessage(http.StatusOK, "no changes were made")
+	c.patch("/api/v1/users/updatemyprofile", map[string]interface{}{"time_zone": "Mars/Olympus"}).expect(http.StatusBadRequest)
+	c.patch("/api/v1/users/updatemyprofile", map[string]interface{}{"password": "short", "current_password": testPassword}).
+		expect(http.StatusBadRequest)
+	c.patch("/api/v1/users/updatemyprofile", map[string]interface{}{"email": "bob@example.com", "current_password": testPassword}).
+		expectMessage(http.StatusBadRequest, "email is already in use")
+	c.patch("/api/v1/users/updatemyprofile", map[string]interface{}{"email": "Alicia <alicia@example.com>", "current_password": testPassword}).
+		expectMessage(http.StatusBadRequest, "valid email address")
+	c.patch("/api/v1/users/updatemyprofile", map[string]interface{}{"email": "not-an-email", "current_password": testPassword}).
+		expectMessage(http.StatusBadRequest, "valid email address")
+
+	// Changing how the user logs in needs their current password
+	c.patch("/api/v1/users/updatemyprofile", map[string]interface{}{"email": "alicia@example.com"}).
+		expectMessage(http.StatusBadRequest, "please confirm your current password")
+	c.patch("/api/v1/users/updatemyprofile", map[string]interface{}{"email": "alicia@example.com", "current_password": "a wrong password"}).
+		expect(http.StatusUnauthorized)
+	c.patch("/api/v1/users/updatemyprofile", map[string]interface{}{"password": "another long password"}).
+		expectMessage(http.StatusBadRequest, "please confirm your current password")
+
+	user := c.patch("/api/v1/users/updatemyprofile", map[string]interface{}{"name": "Alicia", "time_zone": "Europe/Paris"}).
+		expectMessage(http.StatusOK, "User successfully updated").object("user")
+	if user["name"] != "Alicia" || user["time_zone"] != "Europe/Paris" || user["username"] != "alice" {
+		t.Fatalf("updated user = %v", user)
+	}
+	if user["email_verified_at"] == nil {
+		t.Fatal("email verification was reset without changing the email")
+	}
+
+	// A new email has to be verified again
+	user = c.patch("/api/v1/users/updatemyprofile", map[string]interface{}{"email": "alicia@example.com", "current_password": testPassword}).
+		expect(http.StatusOK).object("user")
+	if _, ok := user["password"]; ok {
+		t.Fatal("updated user includes the password")
+	}
+	if user["email_verified_at"] != nil {
+		t.Fatal("new email is verified without using a token")
+	}
+	if ts.mail.token("alicia@example.com") == "" {
+		t.Fatal("no verification email was sent to the new address")
+	}
+
+	// A new password replaces the old one
+	user = c.patch("/api/v1/users/updatemyprofile", map[string]interface{}{"password": "another long password", "current_password": testPassword}).
+		expect(http.StatusOK).object("user")
+	if _, ok := user["password"]; ok {
+		t.Fatal("updated user includes the password")
+	}
+	ts.client().post("/api/v1/users/login", map[string]interface{}{"email": "alicia@example.com", "password": testPassword}).
+		expect(http.StatusUnauthorized)
+	ts.client().post("/api/v1/users/login", map[string]interface{}{"email": "alicia@example.com", "password": "another long password"}).
+		expect(http.StatusOK)
+}
+
+func TestGetUsersIsAdminOnly(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.signUp("alice")
+
+	c.get("/api/v1/users/all").expect(http.StatusForbidden)
+}
+
+func TestPromoteAdmins(t *testing.T) {
+	ts := newTestServer(t)
+	previous := initializers.Config.Auth.AdminEmails
+	initializers.Config.Auth.AdminEmails = []string{"alice@example.com"}
+	t.Cleanup(func() { initializers.Config.Auth.AdminEmails = previous })
+
+	// Someone signing up with the listed address first is not promoted
+	c := ts.client()
+	c.post("/api/v1/users/signup", map[string]interface{}{
+		"name": "Alice", "username": "alice", "email": "alice@example.com", "password": testPassword,
+	}).expect(http.StatusCreated)
+	initializers.PromoteAdmins()
+	c.get("/api/v1/users/all").expect(http.StatusForbidden)
+
+	// Once the address is verified it is, without waiting for a restart
+	c.post("/api/v1/users/verify", map[string]interface{}{"token": ts.mail.token("alice@example.com")}).expect(http.StatusOK)
+	c.get("/api/v1/users/all").expect(http.StatusOK)
+}
+
+func TestAccessTokensCannotManageCredentials(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.signUp("alice")
+
+	token := c.post("/api/v1/users/tokens", map[string]interface{}{"name": "cli", "scopes": []string{"admin"}}).
+		expect(http.StatusCreated).body["token"].(string)
+	cli := ts.client()
+	cli.bearer = token
+
+	// Even an admin token cannot reach the routes that manage credentials
+	cli.get("/api/v1/users/me/export").expect(http.StatusOK)
+	cli.post("/api/v1/users/tokens", map[string]interface{}{"name": "more", "scopes": []string{"admin"}}).
+		expectMessage(http.StatusForbidden, "access tokens cannot be used here")
+	cli.post("/api/v1/users/password", map[string]interface{}{"password": "a new password to use"}).
+		expectMessage(http.StatusForbidden, "access tokens cannot be used here")
+	cli.post("/api/v1/users/2fa/enroll", nil).expectMessage(http.StatusForbidden, "access tokens cannot be used here")
+	cli.delete("/api/v1/users/sessions").expectMessage(http.StatusForbidden, "access tokens cannot be used here")
+	cli.delete("/api/v1/users/me").expectMessage(http.StatusForbidden, "access tokens cannot be used here")
+
+	// Resetting the password revokes the user's tokens
+	ts.client().post("/api/v1/users/password/forgot", map[string]interface{}{"email": "alice@example.com"}).expect(http.StatusOK)
+	ts.client().post("/api/v1/users/password/reset", map[string]interface{}{"token": ts.mail.awaitToken("alice@example.com"), "password": "a new password to use"}).
+		expect(http.StatusOK)
+	cli.get("/api/v1/todos/my").expect(http.StatusUnauthorized)
+}
+
+func TestDisablingUserRevokesAccessTokens(t *testing.T) {
+	ts := newTestServer(t)
+	admin := ts.signUp("alice")
+	initializers.DB.Model(&models.User{}).Where("email = ?", "alice@example.com").Update("role", models.RoleAdmin)
+	bob := ts.signUp("bob")
+
+	token := bob.post("/api/v1/users/tokens", map[string]interface{}{"name": "cli", "scopes": []string{"todos:read"}}).
+		expect(http.StatusCreated).body["token"].(string)
+	bobID := id(bob.get("/api/v1/users/me").object("user"))
+
+	// The token stays revoked once the account is enabled again
+	admin.post("/api/v1/admin/users/"+bobID+"/disable", nil).expect(http.StatusOK)
+	admin.post("/api/v1/admin/users/"+bobID+"/enable", nil).expect(http.StatusOK)
+	cli := ts.client()
+	cli.bearer = token
+	cli.get("/api/v1/todos/my").expect(http.StatusUnauthorized)
+}
+
+func TestTwoFactorCodesWorkOnce(t *testing.T) {
+	ts := newTestServer(t)
+	c := ts.signUp("alice")
+
+	secret := c.post("/api/v1/users/2fa/enroll", nil).expect(http.StatusOK).body["secret"].(string)
+	step := utils.TOTPStep(time.Now())
+	code := func(step int64) string {
+		code, err := utils.TOTPCode(secret, step)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return code
+	}
+	c.post("/api/v1/users/2fa/confirm", map[string]interface{}{"code": code(step)}).expect(http.StatusOK)
+
+	login := func(code string) *response {
+		c := ts.client()
+		mfaToken := c.post("/api/v1/users/login", map[string]interface{}{"email": "alice@example.com", "password": testPassword}).
+			expect(http.StatusOK).body["mfa_token"].(string)
+		return c.post("/api/v1/users/login/2fa", map[string]interface{}{"mfa_token": mfaToken, "code": code})
+	}
+
+	// The code that confirmed enrollment cannot log in, and the next one
+	// only logs in once
+	login(code(step)).expectMessage(http.StatusUnauthorized, "invalid two-factor code")
+	login(code(step + 1)).expect(http.StatusOK)
+	login(code(step+1)).expectMessage(http.StatusUnauthorized, "invalid two-factor code")
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/mailers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+	"gorm.io/gorm"
+)
+
+var ErrAccountPendingDeletion = errors.New("account is scheduled for deletion, log in to cancel it")
+
+// AccountDeletionGrace returns how long a deleted account can still be
+// restored by logging in, set with ACCOUNT_DELETION_GRACE.
+func AccountDeletionGrace() time.Duration {
+	return initializers.Config.Retention.AccountDeletionGrace
+}
+
+// ScheduleAccountDeletion marks the account to be deleted once the grace
+// period is over and signs it out everywhere. Logging in again before then
+// cancels the deletion.
+func ScheduleAccountDeletion(userID uint) (time.Time, error) {
+	now := time.Now()
+	dueAt := now.Add(AccountDeletionGrace())
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("id = ?", userID).Update("deletion_due_at", dueAt).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error
+	})
+	return dueAt, err
+}
+
+// SendAccountDeletionNotice emails the user when their account will be
+// deleted and how to keep it.
+func SendAccountDeletionNotice(ctx context.Context, user models.User, dueAt time.Time) error {
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s,\n\nYour account is scheduled for deletion on %s.\n", user.Name, dueAt.In(user.Location()).Format("January 2, 2006 15:04 MST"))
+	fmt.Fprintf(&body, "Your todos and everything else in it will be removed then.\n")
+	fmt.Fprintf(&body, "\nChanged your mind? Log in before then and the deletion is cancelled.\n")
+
+	return initializers.Mailer.Send(ctx, mailers.Message{
+		To:      user.Email,
+		Subject: "Your account will be deleted",
+		Body:    body.String(),
+	})
+}
+
+// CancelAccountDeletion clears a scheduled deletion of the user's account
+// and reports whether there was one.
+func CancelAccountDeletion(user *models.User) (bool, error) {
+	if user.DeletionDueAt == nil {
+		return false, nil
+	}
+
+	if err := initializers.DB.Model(&models.User{}).Where("id = ?", user.ID).Update("deletion_due_at", nil).Error; err != nil {
+		return false, err
+	}
+	user.DeletionDueAt = nil
+	return true, nil
+}
+
+// PurgeDeletedAccounts deletes the accounts whose grace period is over and
+// returns how many were deleted.
+func PurgeDeletedAccounts() (int, error) {
+	var ids []uint
+	result := initializers.DB.Model(&models.User{}).
+		Where("deletion_due_at IS NOT NULL AND deletion_due_at < ?", time.Now()).
+		Pluck("id", &ids)
+	if result.Error != nil {
+		return 0, result.Error
+	}
+
+	deleted := 0
+	for _, id := range ids {
+		err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+			// Skip the account if the user logged in since it was picked
+			var user models.User
+			err := tx.Where("id = ? AND deletion_due_at IS NOT NULL AND deletion_due_at < ?", id, time.Now()).First(&user).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := deleteAccountData(tx, user); err != nil {
+				return err
+			}
+			if err := tx.Delete(&user).Error; err != nil {
+				return err
+			}
+			deleted++
+			return nil
+		})
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// deleteAccountData removes everything that belongs to a user: their todos
+// (trashed ones and ones shared with others included), projects, tags,
+// shares, credentials and login history.
+func deleteAccountData(tx *gorm.DB, user models.User) error {
+	userID := user.ID
+
+	var todoIDs []uint
+	if result := tx.Unscoped().Model(&models.Todo{}).Where("user_id = ?", userID).Pluck("id", &todoIDs); result.Error != nil {
+		return result.Error
+	}
+	if err := repositories.PurgeTodos(tx, todoIDs); err != nil {
+		return err
+	}
+
+	// Tags are attached to todos through todo_tags, also on todos of others
+	var tagIDs []uint
+	if result := tx.Unscoped().Model(&models.Tag{}).Where("user_id = ?", userID).Pluck("id", &tagIDs); result.Error != nil {
+		return result.Error
+	}
+	if len(tagIDs) > 0 {
+		if result := tx.Exec("DELETE FROM todo_tags WHERE tag_id IN ?", tagIDs); result.Error != nil {
+			return result.Error
+		}
+	}
+
+	for _, model := range []interface{}{
+		&models.Tag{},
+		&models.Project{},
+		&models.TodoShare{},
+		&models.RefreshToken{},
+		&models.Session{},
+		&models.PersonalAccessToken{},
+		&models.OneTimeToken{},
+		&models.RecoveryCode{},
+		&models.UserIdentity{},
+		&models.LoginAttempt{},
+	} {
+		if result := tx.Unscoped().Where("user_id = ?", userID).Delete(model); result.Error != nil {
+			return result.Error
+		}
+	}
+
+	// Failed logins with their email from before they signed up
+	if result := tx.Where("email = ?", user.Email).Delete(&models.LoginAttempt{}); result.Error != nil {
+		return result.Error
+	}
+	return tx.Where("link_user_id = ?", userID).Delete(&models.OIDCLoginState{}).Error
+}
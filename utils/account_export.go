@@ -0,0 +1,98 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+)
+
+// exportFile is one JSON file of an account export.
+type exportFile struct {
+	Name string
+	Data interface{}
+}
+
+// BuildAccountExport collects the user's data into a zip archive with one
+// JSON file per kind of record. The profile is passed in as the caller shows
+// it, without secrets.
+func BuildAccountExport(user models.User, profile interface{}) ([]byte, error) {
+	var (
+		todos         []models.Todo
+		projects      []models.Project
+		tags          []models.Tag
+		sharesGranted []models.TodoShare
+		sharesWithMe  []models.TodoShare
+		sessions      []models.Session
+		tokens        []models.PersonalAccessToken
+		identities    []models.UserIdentity
+		loginAttempts []models.LoginAttempt
+	)
+
+	// Todos include subtasks, trashed ones and past occurrences
+	if err := initializers.DB.Unscoped().Preload("Tags").Where("user_id = ?", user.ID).Order("id ASC").Find(&todos).Error; err != nil {
+		return nil, err
+	}
+
+	// Shares of the user's todos, with who they are shared with
+	sharesGranted = []models.TodoShare{}
+	err := initializers.DB.Model(&models.TodoShare{}).
+		Select("todo_shares.*, users.user_name, users.email").
+		Joins("JOIN users ON users.id = todo_shares.user_id").
+		Joins("JOIN todos ON todos.id = todo_shares.todo_id").
+		Where("todos.user_id = ?", user.ID).
+		Order("todo_shares.id ASC").
+		Scan(&sharesGranted).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, records := range []interface{}{&projects, &tags, &sharesWithMe, &sessions, &tokens, &identities, &loginAttempts} {
+		if err := initializers.DB.Where("user_id = ?", user.ID).Order("id ASC").Find(records).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	files := []exportFile{
+		{"profile.json", profile},
+		{"todos.json", todos},
+		{"projects.json", projects},
+		{"tags.json", tags},
+		{"shares.json", map[string]interface{}{
+			"granted":     sharesGranted,
+			"shared_with": sharesWithMe,
+		}},
+		{"sessions.json", sessions},
+		{"access_tokens.json", tokens},
+		{"identities.json", identities},
+		{"login_attempts.json", loginAttempts},
+	}
+
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	exportedAt := time.Now()
+	for _, file := range files {
+		data, err := json.MarshalIndent(file.Data, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		entry, err := writer.CreateHeader(&zip.FileHeader{
+			Name:     file.Name,
+			Method:   zip.Deflate,
+			Modified: exportedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := entry.Write(data); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return archive.Bytes(), nil
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Layouts accepted for dates that carry no UTC offset. They are read in the
+// user's time zone; date-only values mean midnight of that day.
+var localTimeLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	time.DateOnly,
+}
+
+const maxUpcomingWindow = 365 * 24 * time.Hour
+
+// ValidateTimeZone checks that timeZone is a known IANA time zone name.
+func ValidateTimeZone(timeZone string) error {
+	if _, err := time.LoadLocation(timeZone); err != nil || timeZone == "" {
+		return fmt.Errorf("unknown time zone %q", timeZone)
+	}
+	return nil
+}
+
+// ParseUserTime parses an RFC 3339 timestamp, or a local date and time which
+// is interpreted in the given location.
+func ParseUserTime(value string, location *time.Location) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed.UTC(), nil
+	}
+	for _, layout := range localTimeLayouts {
+		if parsed, err := time.ParseInLocation(layout, value, location); err == nil {
+			return parsed.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q, use RFC 3339 or YYYY-MM-DD[THH:MM]", value)
+}
+
+// ParseTodoDates converts the due and reminder dates sent in a request body,
+// read in location, and checks that they are consistent. A nil value was not
+// sent and an empty string clears the date. currentDueAt is the todo's due
+// date when editing, so a new reminder is checked against it as well.
+func ParseTodoDates(rawDueAt, rawRemindAt *string, location *time.Location, currentDueAt *time.Time) (dueAt, remindAt *time.Time, err error) {
+	if rawDueAt != nil && *rawDueAt != "" {
+		parsed, err := ParseUserTime(*rawDueAt, location)
+		if err != nil {
+			return nil, nil, fmt.Errorf("due_at: %v", err)
+		}
+		dueAt = &parsed
+	}
+	if rawRemindAt != nil && *rawRemindAt != "" {
+		parsed, err := ParseUserTime(*rawRemindAt, location)
+		if err != nil {
+			return nil, nil, fmt.Errorf("remind_at: %v", err)
+		}
+		if parsed.Before(time.Now()) {
+			return nil, nil, fmt.Errorf("remind_at must be in the future")
+		}
+		remindAt = &parsed
+	}
+
+	// A reminder after the todo is due is of no use
+	effectiveDueAt := currentDueAt
+	if rawDueAt != nil {
+		effectiveDueAt = dueAt
+	}
+	if remindAt != nil && effectiveDueAt != nil && remindAt.After(*effectiveDueAt) {
+		return nil, nil, fmt.Errorf("remind_at must not be after due_at")
+	}
+
+	return dueAt, remindAt, nil
+}
+
+// ParseDuration parses a Go duration such as "36h" or "90m", or a number of
+// days such as "7d".
+func ParseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		count, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(count) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// ParseWithin parses a look-ahead window such as "7d", "36h" or "90m".
+func ParseWithin(value string) (time.Duration, error) {
+	within, err := ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("within must look like 7d, 36h or 90m")
+	}
+	if within <= 0 || within > maxUpcomingWindow {
+		return 0, fmt.Errorf("within must be positive and at most 365d")
+	}
+	return within, nil
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/mailers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"gorm.io/gorm"
+)
+
+const (
+	verificationResendInterval = time.Minute // Least time between two verification emails
+	verificationHourlyLimit    = 5           // Most verification emails a user can get in an hour
+)
+
+var ErrEmailAlreadyVerified = errors.New("email is already verified")
+
+// ResendTooSoonError is returned when a verification email was asked for too
+// soon after the last ones.
+type ResendTooSoonError struct {
+	RetryAfter time.Duration
+}
+
+func (err *ResendTooSoonError) Error() string {
+	return fmt.Sprintf("please wait %d seconds before asking for another email", int(err.RetryAfter.Seconds()+0.5))
+}
+
+// EmailVerificationTTL returns how long a verification token is valid, read
+// with EMAIL_VERIFICATION_TTL.
+func EmailVerificationTTL() time.Duration {
+	return initializers.Config.Auth.EmailVerificationTTL
+}
+
+// SendEmailVerification issues a verification token for the user's current
+// email and sends it there.
+func SendEmailVerification(ctx context.Context, user models.User) error {
+	rawToken, err := IssueOneTimeToken(user.ID, models.TokenPurposeEmailVerification, EmailVerificationTTL())
+	if err != nil {
+		return err
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s,\n\nPlease confirm this is your email address.\n", user.Name)
+	fmt.Fprintf(&body, "%s\n", tokenInstructions("verify-email", rawToken))
+	fmt.Fprintf(&body, "\nIt expires in %s.\n", EmailVerificationTTL())
+
+	return initializers.Mailer.Send(ctx, mailers.Message{
+		To:      user.Email,
+		Subject: "Verify your email",
+		Body:    body.String(),
+	})
+}
+
+// ResendEmailVerification sends a new verification email unless the user is
+// verified already or has been sent one too recently.
+func ResendEmailVerification(ctx context.Context, user models.User) error {
+	if user.EmailVerifiedAt != nil {
+		return ErrEmailAlreadyVerified
+	}
+
+	var recent []models.OneTimeToken
+	result := initializers.DB.
+		Where("user_id = ? AND purpose = ? AND created_at > ?", user.ID, models.TokenPurposeEmailVerification, time.Now().Add(-time.Hour)).
+		Order("created_at DESC").
+		Find(&recent)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if len(recent) > 0 {
+		if wait := verificationResendInterval - time.Since(recent[0].CreatedAt); wait > 0 {
+			return &ResendTooSoonError{RetryAfter: wait}
+		}
+	}
+	if len(recent) >= verificationHourlyLimit {
+		oldest := recent[verificationHourlyLimit-1].CreatedAt
+		return &ResendTooSoonError{RetryAfter: time.Until(oldest.Add(time.Hour))}
+	}
+
+	return SendEmailVerification(ctx, user)
+}
+
+// VerifyEmail marks the email of the token's user as verified, and makes
+// them an admin when the address is listed in ADMIN_EMAILS.
+func VerifyEmail(rawToken string) error {
+	return initializers.DB.Transaction(func(tx *gorm.DB) error {
+		token, err := ConsumeOneTimeToken(tx, rawToken, models.TokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", token.UserID).Update("email_verified_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return initializers.PromoteAdmin(tx, token.UserID)
+	})
+}
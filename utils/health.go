@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/migrations"
+)
+
+// readinessTimeout bounds each readiness check, so a hanging dependency
+// fails the probe instead of stalling it.
+const readinessTimeout = 2 * time.Second
+
+// ReadinessCheck is a dependency the server needs to handle requests.
+type ReadinessCheck struct {
+	Name  string
+	Check func(ctx context.Context) error
+}
+
+// ReadinessChecks lists what has to work before the server takes traffic.
+func ReadinessChecks() []ReadinessCheck {
+	return []ReadinessCheck{
+		{"database", checkDatabase},
+		{"schema", checkSchema},
+		{"signing_keys", func(ctx context.Context) error { return CheckSigningKeys() }},
+	}
+}
+
+// CheckReadiness runs every readiness check and returns the failures by
+// check name, nil when the server is ready.
+func CheckReadiness(ctx context.Context) map[string]error {
+	var failures map[string]error
+	for _, check := range ReadinessChecks() {
+		checkCtx, cancel := context.WithTimeout(ctx, readinessTimeout)
+		err := check.Check(checkCtx)
+		cancel()
+		if err != nil {
+			if failures == nil {
+				failures = map[string]error{}
+			}
+			failures[check.Name] = err
+		}
+	}
+	return failures
+}
+
+func checkDatabase(ctx context.Context) error {
+	sqlDB, err := initializers.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+var (
+	latestMigrationOnce sync.Once
+	latestMigration     uint
+	latestMigrationErr  error
+)
+
+// checkSchema fails while migrations are pending, such as during a deploy
+// that has not migrated yet. Probes only read the newest applied version,
+// compared with the newest migration of this binary.
+func checkSchema(ctx context.Context) error {
+	latestMigrationOnce.Do(func() {
+		var dialect string
+		if dialect, latestMigrationErr = migrations.Dialect(initializers.DB); latestMigrationErr != nil {
+			return
+		}
+		var known []migrations.Migration
+		if known, latestMigrationErr = migrations.Load(dialect); latestMigrationErr == nil && len(known) > 0 {
+			latestMigration = known[len(known)-1].Version
+		}
+	})
+	if latestMigrationErr != nil {
+		return latestMigrationErr
+	}
+
+	current, err := migrations.Current(initializers.DB.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	if current < latestMigration {
+		return fmt.Errorf("database schema is at migration %d, want %d", current, latestMigration)
+	}
+	return nil
+}
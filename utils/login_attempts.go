@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	loginIPFailureFactor = 4                // An IP may fail this many times more than one account
+	loginBaseDelay       = 30 * time.Second // Lockout after the first failure over the limit, doubling after that
+	loginMaxDelay        = 15 * time.Minute
+)
+
+// Reasons recorded on failed login attempts.
+const (
+	LoginFailureUnknownEmail  = "unknown_email"
+	LoginFailureWrongPassword = "wrong_password"
+	LoginFailureWrongCode     = "wrong_2fa_code"
+)
+
+var ErrInvalidCredentials = errors.New("invalid email or password")
+
+// LoginLockedError is returned while an account or IP is locked out.
+type LoginLockedError struct {
+	RetryAfter time.Duration
+}
+
+func (err *LoginLockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %d seconds", int(err.RetryAfter.Seconds()+0.5))
+}
+
+// LoginMaxFailures returns how many failed logins an account may have within
+// the window before it is locked, set with LOGIN_MAX_FAILURES.
+func LoginMaxFailures() int {
+	return initializers.Config.Auth.LoginMaxFailures
+}
+
+// LoginWindow returns how far back failed logins count, set with
+// LOGIN_LOCKOUT_WINDOW.
+func LoginWindow() time.Duration {
+	return initializers.Config.Auth.LoginLockoutWindow
+}
+
+// NormalizeLoginEmail is how emails are compared when counting attempts.
+func NormalizeLoginEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// CheckLoginAllowed returns a *LoginLockedError while the account or the
+// client's IP has failed too often. Past the limit every further failure
+// doubles the wait.
+func CheckLoginAllowed(ctx *gin.Context, email string) error {
+	now := time.Now()
+
+	accountWait, err := loginBackoff(initializers.DB.Where("email = ?", NormalizeLoginEmail(email)), LoginMaxFailures(), true, now)
+	if err != nil {
+		return err
+	}
+
+	// Successful logins do not reset the IP count, or an attacker could clear
+	// it with an account of their own
+	ipWait, err := loginBackoff(initializers.DB.Where("ip = ?", ctx.ClientIP()), LoginMaxFailures()*loginIPFailureFactor, false, now)
+	if err != nil {
+		return err
+	}
+
+	if wait := max(accountWait, ipWait); wait > 0 {
+		return &LoginLockedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// RecordLoginAttempt stores the outcome of a login attempt. reason is empty
+// for successful ones.
+func RecordLoginAttempt(ctx *gin.Context, email string, userID *uint, reason string) error {
+	return initializers.DB.Create(&models.LoginAttempt{
+		UserID:    userID,
+		Email:     NormalizeLoginEmail(email),
+		IP:        ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		Success:   reason == "",
+		Reason:    reason,
+	}).Error
+}
+
+func loginBackoff(scope *gorm.DB, allowed int, resetOnSuccess bool, now time.Time) (time.Duration, error) {
+	since := now.Add(-LoginWindow())
+
+	if resetOnSuccess {
+		var success models.LoginAttempt
+		result := scope.Session(&gorm.Session{}).
+			Where("success = ? AND created_at > ?", true, since).
+			Order("created_at DESC").Limit(1).Find(&success)
+		if result.Error != nil {
+			return 0, result.Error
+		}
+		if result.RowsAffected > 0 {
+			since = success.CreatedAt
+		}
+	}
+
+	// Counted in one query, an IP under attack can have many rows
+	var failures int64
+	var lastFailure aggregateTime
+	err := scope.Session(&gorm.Session{}).
+		Model(&models.LoginAttempt{}).
+		Select("COUNT(*), MAX(created_at)").
+		Where("success = ? AND created_at > ?", false, since).
+		Row().Scan(&failures, &lastFailure)
+	if err != nil {
+		return 0, err
+	}
+	if failures < int64(allowed) || lastFailure.IsZero() {
+		return 0, nil
+	}
+
+	delay := loginMaxDelay
+	if over := failures - int64(allowed); over < 16 {
+		delay = min(loginBaseDelay<<over, loginMaxDelay)
+	}
+	return max(lastFailure.Add(delay).Sub(now), 0), nil
+}
+
+// aggregateTime scans MAX(created_at), which SQLite returns as text since
+// the result of an aggregate has no column type.
+type aggregateTime struct {
+	time.Time
+}
+
+func (value *aggregateTime) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		value.Time = time.Time{}
+	case time.Time:
+		value.Time = src
+	case string:
+		return value.parse(src)
+	case []byte:
+		return value.parse(string(src))
+	default:
+		return fmt.Errorf("cannot scan %T into a time", src)
+	}
+	return nil
+}
+
+func (value *aggregateTime) parse(text string) error {
+	for _, layout := range []string{"2006-01-02 15:04:05.999999999-07:00", time.RFC3339Nano, "2006-01-02 15:04:05.999999999"} {
+		if parsed, err := time.Parse(layout, text); err == nil {
+			value.Time = parsed
+			return nil
+		}
+	}
+	return fmt.Errorf("cannot parse %q as a time", text)
+}
+
+// RecentLoginAttempts lists the last 50 attempts on the user's account,
+// including those against its email that did not match the password.
+func RecentLoginAttempts(user models.User) ([]models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	result := initializers.DB.
+		Where("user_id = ? OR email = ?", user.ID, NormalizeLoginEmail(user.Email)).
+		Order("created_at DESC").
+		Limit(50).
+		Find(&attempts)
+	return attempts, result.Error
+}
+
+// PurgeLoginAttempts deletes attempts older than retention and returns how
+// many were deleted.
+func PurgeLoginAttempts(retention time.Duration) (int64, error) {
+	result := initializers.DB.Where("created_at < ?", time.Now().Add(-retention)).Delete(&models.LoginAttempt{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"gorm.io/gorm"
+)
+
+// oidcStateTTL is how long the user has to finish logging in at the provider.
+const oidcStateTTL = 10 * time.Minute
+
+var (
+	ErrInvalidOIDCState = errors.New("login request is invalid or has expired, please try again")
+	ErrIdentityInUse    = errors.New("this identity is already linked to another account")
+	ErrOIDCEmailTaken   = errors.New("an account with this email already exists, login with your password and link the identity from your account")
+)
+
+// BeginOIDCLogin stores a new authorization request for the provider and
+// returns the URL to send the user to, along with the state the callback has
+// to bring back. linkUserID is set when a logged in user is linking the
+// identity rather than logging in with it.
+func BeginOIDCLogin(ctx context.Context, providerName string, linkUserID *uint) (string, string, error) {
+	provider, err := initializers.OIDC.Get(ctx, providerName)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := NewRandomToken()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := NewRandomToken()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := NewRandomToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	// Drop requests nobody came back from
+	initializers.DB.Where("expires_at < ?", time.Now()).Delete(&models.OIDCLoginState{})
+
+	err = initializers.DB.Create(&models.OIDCLoginState{
+		StateHash:    HashToken(state),
+		Provider:     providerName,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		LinkUserID:   linkUserID,
+		ExpiresAt:    time.Now().Add(oidcStateTTL),
+	}).Error
+	if err != nil {
+		return "", "", err
+	}
+
+	return provider.AuthCodeURL(state, nonce, verifier), state, nil
+}
+
+// CompleteOIDCLogin handles the provider's callback once the state has been
+// matched to the browser's state cookie. It checks the state,
+// exchanges the code, and returns the user the identity belongs to, linking
+// or creating one as needed.
+func CompleteOIDCLogin(ctx context.Context, providerName string, state string, code string) (models.User, error) {
+	var user models.User
+
+	// Each state works once
+	var loginState models.OIDCLoginState
+	if err := initializers.DB.Where("state_hash = ? AND provider = ?", HashToken(state), providerName).First(&loginState).Error; err != nil {
+		return user, ErrInvalidOIDCState
+	}
+	if result := initializers.DB.Delete(&loginState); result.Error != nil || result.RowsAffected == 0 {
+		return user, ErrInvalidOIDCState
+	}
+	if time.Now().After(loginState.ExpiresAt) {
+		return user, ErrInvalidOIDCState
+	}
+
+	provider, err := initializers.OIDC.Get(ctx, providerName)
+	if err != nil {
+		return user, err
+	}
+	claims, err := provider.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		return user, err
+	}
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var identity models.UserIdentity
+		result := tx.Where("provider = ? AND subject = ?", providerName, claims.Subject).Limit(1).Find(&identity)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// Linking from a logged in account
+		if loginState.LinkUserID != nil {
+			if result.RowsAffected > 0 && identity.UserID != *loginState.LinkUserID {
+				return ErrIdentityInUse
+			}
+			if err := tx.First(&user, *loginState.LinkUserID).Error; err != nil {
+				return err
+			}
+			if result.RowsAffected > 0 {
+				return nil
+			}
+			return tx.Create(&models.UserIdentity{UserID: user.ID, Provider: providerName, Subject: claims.Subject, Email: claims.Email}).Error
+		}
+
+		// Returning user
+		if result.RowsAffected > 0 {
+			if err := tx.First(&user, identity.UserID).Error; err != nil {
+				return err
+			}
+			return tx.Model(&identity).Updates(map[string]interface{}{"email": claims.Email, "last_login_at": now}).Error
+		}
+
+		// An account with the same email is only linked when both sides have
+		// verified the address, otherwise anyone able to claim the email at
+		// the provider could take the account over
+		if claims.Email != "" {
+			existing := tx.Where("email = ?", claims.Email).Limit(1).Find(&user)
+			if existing.Error != nil {
+				return existing.Error
+			}
+			if existing.RowsAffected > 0 {
+				if !claims.EmailVerified || user.EmailVerifiedAt == nil {
+					return ErrOIDCEmailTaken
+				}
+				return tx.Create(&models.UserIdentity{UserID: user.ID, Provider: providerName, Subject: claims.Subject, Email: claims.Email, LastLoginAt: &now}).Error
+			}
+		}
+
+		// New user without a local password
+		user = models.User{
+			Name:     claims.Name,
+			UserName: oidcUserName(claims.PreferredUsername, claims.Email, claims.Subject),
+			Email:    claims.Email,
+			TimeZone: "UTC",
+			Role:     models.RoleUser,
+		}
+		if user.Name == "" {
+			user.Name = user.UserName
+		}
+		if user.Email == "" {
+			user.Email = providerName + ":" + claims.Subject
+		}
+		if claims.EmailVerified && claims.Email != "" {
+			user.EmailVerifiedAt = &now
+		}
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		if err := initializers.PromoteAdmin(tx, user.ID); err != nil {
+			return err
+		}
+		return tx.Create(&models.UserIdentity{UserID: user.ID, Provider: providerName, Subject: claims.Subject, Email: claims.Email, LastLoginAt: &now}).Error
+	})
+	return user, err
+}
+
+func oidcUserName(preferred string, email string, subject string) string {
+	if preferred != "" {
+		return preferred
+	}
+	if name, _, found := strings.Cut(email, "@"); found && name != "" {
+		return name
+	}
+	return subject
+}
@@ -0,0 +1,66 @@
+package utils
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidOneTimeToken = errors.New("invalid or expired token")
+
+// IssueOneTimeToken creates a token for the user valid for ttl and returns the
+// raw token. Earlier unused tokens for the same purpose stop working, so only
+// the newest email is good.
+func IssueOneTimeToken(userID uint, purpose string, ttl time.Duration) (string, error) {
+	rawToken, err := NewRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = initializers.DB.Transaction(func(tx *gorm.DB) error {
+		if err := expireOneTimeTokens(tx, userID, purpose); err != nil {
+			return err
+		}
+		return tx.Create(&models.OneTimeToken{
+			UserID:    userID,
+			Purpose:   purpose,
+			TokenHash: HashToken(rawToken),
+			ExpiresAt: time.Now().Add(ttl),
+		}).Error
+	})
+	return rawToken, err
+}
+
+// ConsumeOneTimeToken marks an unexpired token for purpose as used and
+// returns it. A token can only be consumed once.
+func ConsumeOneTimeToken(tx *gorm.DB, rawToken string, purpose string) (models.OneTimeToken, error) {
+	var token models.OneTimeToken
+	result := tx.Where("token_hash = ? AND purpose = ?", HashToken(rawToken), purpose).First(&token)
+	if result.Error != nil {
+		return token, ErrInvalidOneTimeToken
+	}
+	if token.UsedAt != nil || time.Now().After(token.ExpiresAt) {
+		return token, ErrInvalidOneTimeToken
+	}
+
+	// Only the first of two concurrent requests gets to use the token
+	result = tx.Model(&models.OneTimeToken{}).
+		Where("id = ? AND used_at IS NULL", token.ID).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return token, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return token, ErrInvalidOneTimeToken
+	}
+	return token, nil
+}
+
+func expireOneTimeTokens(tx *gorm.DB, userID uint, purpose string) error {
+	return tx.Model(&models.OneTimeToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var errUnknownPasswordHash = errors.New("unknown password hash format")
+
+// argon2Params are the cost settings of an argon2id hash.
+type argon2Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+}
+
+// PasswordHasher returns the algorithm new passwords are hashed with, set
+// with PASSWORD_HASHER: argon2id (the default) or bcrypt.
+func PasswordHasher() string {
+	return initializers.Config.Passwords.Hasher
+}
+
+// BcryptCost returns the cost new bcrypt hashes use, set with BCRYPT_COST.
+func BcryptCost() int {
+	return initializers.Config.Passwords.BcryptCost
+}
+
+// currentArgon2Params returns the cost settings new argon2id hashes use, set
+// with ARGON2_MEMORY (in KiB), ARGON2_ITERATIONS and ARGON2_PARALLELISM.
+func currentArgon2Params() argon2Params {
+	return argon2Params{
+		Memory:      initializers.Config.Passwords.Argon2Memory,
+		Iterations:  initializers.Config.Passwords.Argon2Iterations,
+		Parallelism: initializers.Config.Passwords.Argon2Parallelism,
+	}
+}
+
+// HashPassword hashes a password with the configured hasher. Argon2id hashes
+// are stored in the PHC string format, e.g.
+// $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>.
+func HashPassword(password string) (string, error) {
+	switch hasher := PasswordHasher(); hasher {
+	case "argon2id":
+		salt := make([]byte, argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", err
+		}
+		params := currentArgon2Params()
+		key := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, argon2KeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version, params.Memory, params.Iterations, params.Parallelism,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	case "bcrypt":
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), BcryptCost())
+		return string(hashedPassword), err
+	default:
+		return "", fmt.Errorf("unsupported PASSWORD_HASHER %q", hasher)
+	}
+}
+
+func IsPasswordMatches(userPassword *string, existingUserPassword *string) error {
+	if err := comparePassword(*existingUserPassword, *userPassword); err != nil {
+		return fmt.Errorf("password does not matches")
+	}
+	return nil
+}
+
+// comparePassword checks a password against an argon2id or bcrypt hash.
+func comparePassword(hash string, password string) error {
+	if strings.HasPrefix(hash, "$argon2id$") {
+		params, salt, key, err := decodeArgon2Hash(hash)
+		if err != nil {
+			return err
+		}
+		candidate := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, uint32(len(key)))
+		if subtle.ConstantTimeCompare(candidate, key) != 1 {
+			return bcrypt.ErrMismatchedHashAndPassword
+		}
+		return nil
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+}
+
+// PasswordNeedsRehash reports whether a stored hash was made with another
+// hasher or weaker settings than new hashes get.
+func PasswordNeedsRehash(hash string) bool {
+	switch PasswordHasher() {
+	case "argon2id":
+		params, _, _, err := decodeArgon2Hash(hash)
+		return err != nil || params != currentArgon2Params()
+	case "bcrypt":
+		cost, err := bcrypt.Cost([]byte(hash))
+		return err != nil || cost != BcryptCost()
+	}
+	return false
+}
+
+// RehashPassword replaces the user's stored hash after a successful login
+// when it uses outdated settings. Failures are only logged, the old hash
+// keeps working.
+func RehashPassword(user *models.User, password string) {
+	if user.Password == "" || !PasswordNeedsRehash(user.Password) {
+		return
+	}
+
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		log.Println("Failed to rehash password:", err)
+		return
+	}
+
+	// Only replace the hash that was just checked, in case the password
+	// changed in the meantime
+	result := initializers.DB.Model(&models.User{}).
+		Where("id = ? AND password = ?", user.ID, user.Password).
+		Update("password", hashedPassword)
+	if result.Error != nil {
+		log.Println("Failed to rehash password:", result.Error)
+		return
+	}
+	user.Password = hashedPassword
+}
+
+func decodeArgon2Hash(hash string) (argon2Params, []byte, []byte, error) {
+	var params argon2Params
+
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, errUnknownPasswordHash
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, errUnknownPasswordHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return params, nil, nil, errUnknownPasswordHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, errUnknownPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, errUnknownPasswordHash
+	}
+	return params, salt, key, nil
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+// CompareDummyPassword spends as long as a real password check, so a login
+// for an unknown email takes as long as one with a wrong password.
+func CompareDummyPassword(password string) {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = HashPassword("not-a-real-password")
+	})
+	comparePassword(dummyHash, password)
+}
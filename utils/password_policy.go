@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+)
+
+const (
+	minPasswordLength    = 8
+	maxPasswordLength    = 128
+	maxBcryptPasswordLen = 72 // bcrypt only looks at this many bytes
+)
+
+// Character classes PASSWORD_REQUIRE can ask for.
+var passwordClasses = map[string]struct {
+	description string
+	matches     func(rune) bool
+}{
+	"upper":  {"an uppercase letter", unicode.IsUpper},
+	"lower":  {"a lowercase letter", unicode.IsLower},
+	"digit":  {"a digit", unicode.IsDigit},
+	"symbol": {"a symbol", func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r) }},
+}
+
+var ErrBreachedPassword = errors.New("this password has appeared in a data breach, please choose another one")
+
+// PasswordRequirements returns the character classes a password must
+// contain, set with PASSWORD_REQUIRE as a list of upper, lower, digit and
+// symbol. Nothing is required by default.
+func PasswordRequirements() []string {
+	return initializers.Config.Passwords.Require
+}
+
+func ValidatePassword(user *models.User) error {
+	password := user.Password
+
+	// Length is counted in characters, not bytes
+	length := utf8.RuneCountInString(password)
+	if length < minPasswordLength {
+		return fmt.Errorf("password should contain at least %d characters", minPasswordLength)
+	}
+	if length > maxPasswordLength {
+		return fmt.Errorf("password must not be greater than %d characters", maxPasswordLength)
+	}
+	if PasswordHasher() == "bcrypt" && len(password) > maxBcryptPasswordLen {
+		return fmt.Errorf("password must not be greater than %d bytes", maxBcryptPasswordLen)
+	}
+
+	// Configured complexity rules
+	for _, class := range PasswordRequirements() {
+		rule := passwordClasses[class]
+		if strings.IndexFunc(password, rule.matches) < 0 {
+			return fmt.Errorf("password must contain %s", rule.description)
+		}
+	}
+
+	// Known breached passwords
+	breached, err := IsBreachedPassword(password)
+	if err != nil {
+		// A missing or broken list should not stop people from signing up
+		log.Println("Failed to check breached passwords:", err)
+	}
+	if breached {
+		return ErrBreachedPassword
+	}
+
+	return nil
+}
+
+// IsBreachedPassword looks the password up in the local breached password
+// list at PASSWORD_BREACHED_LIST, using the Have I Been Pwned SHA-1 formats:
+//   - a directory of range files named after the first five hex characters
+//     of the hash (e.g. 5BAA6.txt), holding "SUFFIX:COUNT" lines, or
+//   - a single file of "HASH:COUNT" lines sorted by hash.
+//
+// Only the hash prefix picks what is read, so the list can be as large as
+// the full download. It reports false when no list is configured.
+func IsBreachedPassword(password string) (bool, error) {
+	path := initializers.Config.Passwords.BreachedList
+	if path == "" {
+		return false, nil
+	}
+
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	if info.IsDir() {
+		return breachedRangeContains(filepath.Join(path, hash[:5]+".txt"), hash[5:])
+	}
+	return breachedListContains(path, info.Size(), hash)
+}
+
+// breachedRangeContains scans one range file for the hash suffix.
+func breachedRangeContains(path string, suffix string) (bool, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if breachedLineHash(scanner.Text()) == suffix {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// breachedListContains binary searches a sorted list file for the hash.
+func breachedListContains(path string, size int64, hash string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	// lo and hi bound the offsets where the line could start
+	lo, hi := int64(0), size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		start, end, line, err := lineStartingFrom(file, size, mid)
+		if err != nil {
+			return false, err
+		}
+		if start >= hi {
+			hi = mid
+			continue
+		}
+
+		switch compared := strings.Compare(breachedLineHash(line), hash); {
+		case compared == 0:
+			return true, nil
+		case compared < 0:
+			lo = end
+		default:
+			hi = mid
+		}
+	}
+	return false, nil
+}
+
+// lineStartingFrom reads the first line starting at or after offset,
+// returning where it starts and ends.
+func lineStartingFrom(file *os.File, size int64, offset int64) (int64, int64, string, error) {
+	start := offset
+	if offset > 0 {
+		// Skip the rest of the line offset falls into
+		reader := bufio.NewReader(io.NewSectionReader(file, offset-1, size-offset+1))
+		skipped, err := reader.ReadString('\n')
+		if err == io.EOF {
+			return size, size, "", nil
+		}
+		if err != nil {
+			return 0, 0, "", err
+		}
+		start = offset - 1 + int64(len(skipped))
+	}
+
+	reader := bufio.NewReader(io.NewSectionReader(file, start, size-start))
+	line, err := reader.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return 0, 0, "", err
+	}
+	return start, start + int64(len(line)), line, nil
+}
+
+// breachedLineHash takes the hash out of a "HASH:COUNT" line.
+func breachedLineHash(line string) string {
+	hash, _, _ := strings.Cut(strings.TrimSpace(line), ":")
+	return strings.ToUpper(hash)
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// writeBreachedList writes the hashes of passwords as a sorted list file,
+// with counts of varying length and CRLF line endings like the download.
+func writeBreachedList(t *testing.T, passwords []string) string {
+	t.Helper()
+	hashes := make([]string, len(passwords))
+	for i, password := range passwords {
+		hashes[i] = sha1Hex(password)
+	}
+	sort.Strings(hashes)
+
+	var list strings.Builder
+	for i, hash := range hashes {
+		fmt.Fprintf(&list, "%s:%d\r\n", hash, (i*7919)%100000+1)
+	}
+	path := filepath.Join(t.TempDir(), "pwned-passwords.txt")
+	if err := os.WriteFile(path, []byte(list.String()), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestBreachedListContains(t *testing.T) {
+	var listed []string
+	for i := 0; i < 200; i += 2 {
+		listed = append(listed, fmt.Sprintf("password%d", i))
+	}
+	path := writeBreachedList(t, listed)
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, password := range listed {
+		if found, err := breachedListContains(path, info.Size(), sha1Hex(password)); err != nil || !found {
+			t.Errorf("%s not found: %v", password, err)
+		}
+	}
+
+	// Hashes between the listed ones and past either end of the list
+	absent := []string{strings.Repeat("0", 40), strings.Repeat("F", 40)}
+	for i := 1; i < 200; i += 2 {
+		absent = append(absent, sha1Hex(fmt.Sprintf("password%d", i)))
+	}
+	for _, hash := range absent {
+		if found, err := breachedListContains(path, info.Size(), hash); err != nil || found {
+			t.Errorf("%s found in the list: %v", hash, err)
+		}
+	}
+
+	// A list of one line, and an empty one
+	single := writeBreachedList(t, []string{"hunter22"})
+	info, _ = os.Stat(single)
+	if found, _ := breachedListContains(single, info.Size(), sha1Hex("hunter22")); !found {
+		t.Error("hash not found in a list of one")
+	}
+	empty := writeBreachedList(t, nil)
+	if found, err := breachedListContains(empty, 0, sha1Hex("hunter22")); err != nil || found {
+		t.Errorf("hash found in an empty list: %v", err)
+	}
+}
+
+func TestIsBreachedPassword(t *testing.T) {
+	passwords := cheapArgon2
+	passwords.BreachedList = writeBreachedList(t, []string{"password123", "letmein1"})
+	usePasswords(t, passwords)
+
+	if breached, err := IsBreachedPassword("letmein1"); err != nil || !breached {
+		t.Fatalf("IsBreachedPassword(letmein1) = %v, %v, want true", breached, err)
+	}
+
+	// Range directories hold the hash suffixes in a file per prefix
+	dir := t.TempDir()
+	hash := sha1Hex("password123")
+	if err := os.WriteFile(filepath.Join(dir, hash[:5]+".txt"), []byte(hash[5:]+":42\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	passwords.BreachedList = dir
+	usePasswords(t, passwords)
+
+	if breached, err := IsBreachedPassword("password123"); err != nil || !breached {
+		t.Fatalf("IsBreachedPassword(password123) = %v, %v, want true", breached, err)
+	}
+	if breached, err := IsBreachedPassword("letmein1"); err != nil || breached {
+		t.Fatalf("IsBreachedPassword(letmein1) = %v, %v, want false without a range file", breached, err)
+	}
+}
@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/mailers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// PasswordResetTTL returns how long a password reset token is valid, read
+// with PASSWORD_RESET_TTL.
+func PasswordResetTTL() time.Duration {
+	return initializers.Config.Auth.PasswordResetTTL
+}
+
+const (
+	passwordResetMaxRequests = 3 // Reset emails an address can ask for within the window
+	passwordResetWindow      = time.Hour
+)
+
+// PasswordResetLimitedError is returned while an address or IP has asked for
+// too many reset emails.
+type PasswordResetLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (err *PasswordResetLimitedError) Error() string {
+	return fmt.Sprintf("too many password reset requests, try again in %d seconds", int(err.RetryAfter.Seconds()+0.5))
+}
+
+// CheckPasswordResetAllowed returns a *PasswordResetLimitedError while the
+// email or the client's IP has asked for too many reset emails, counting
+// requests for emails without an account too.
+func CheckPasswordResetAllowed(ctx *gin.Context, email string) error {
+	now := time.Now()
+
+	emailWait, err := passwordResetWait(initializers.DB.Where("email = ?", NormalizeLoginEmail(email)), passwordResetMaxRequests, now)
+	if err != nil {
+		return err
+	}
+	ipWait, err := passwordResetWait(initializers.DB.Where("ip = ?", ctx.ClientIP()), passwordResetMaxRequests*loginIPFailureFactor, now)
+	if err != nil {
+		return err
+	}
+
+	if wait := max(emailWait, ipWait); wait > 0 {
+		return &PasswordResetLimitedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// RecordPasswordResetRequest stores a request for a reset email.
+func RecordPasswordResetRequest(ctx *gin.Context, email string) error {
+	return initializers.DB.Create(&models.PasswordResetRequest{
+		Email: NormalizeLoginEmail(email),
+		IP:    ctx.ClientIP(),
+	}).Error
+}
+
+// passwordResetWait returns how long until the oldest request in the window
+// leaves it, once allowed requests have been made.
+func passwordResetWait(scope *gorm.DB, allowed int, now time.Time) (time.Duration, error) {
+	var requests int64
+	var oldest aggregateTime
+	err := scope.Model(&models.PasswordResetRequest{}).
+		Select("COUNT(*), MIN(created_at)").
+		Where("created_at > ?", now.Add(-passwordResetWindow)).
+		Row().Scan(&requests, &oldest)
+	if err != nil {
+		return 0, err
+	}
+	if requests < int64(allowed) || oldest.IsZero() {
+		return 0, nil
+	}
+	return max(oldest.Add(passwordResetWindow).Sub(now), time.Second), nil
+}
+
+// PurgePasswordResetRequests deletes requests that no longer count and
+// returns how many were deleted.
+func PurgePasswordResetRequests() (int64, error) {
+	result := initializers.DB.Where("created_at < ?", time.Now().Add(-passwordResetWindow)).Delete(&models.PasswordResetRequest{})
+	return result.RowsAffected, result.Error
+}
+
+// SendPasswordReset issues a reset token for the user and emails it.
+func SendPasswordReset(ctx context.Context, user models.User) error {
+	rawToken, err := IssueOneTimeToken(user.ID, models.TokenPurposePasswordReset, PasswordResetTTL())
+	if err != nil {
+		return err
+	}
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Hi %s,\n\nSomeone asked to reset the password of your account.\n", user.Name)
+	fmt.Fprintf(&body, "%s\n", tokenInstructions("reset-password", rawToken))
+	fmt.Fprintf(&body, "\nIt expires in %s. If you did not ask for this, you can ignore this email.\n", PasswordResetTTL())
+
+	return initializers.Mailer.Send(ctx, mailers.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body:    body.String(),
+	})
+}
+
+// ResetPassword sets a new password using a reset token, then signs the user
+// out of every session and revokes their access tokens.
+func ResetPassword(rawToken string, password string) error {
+	hashedPassword, err := HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	return initializers.DB.Transaction(func(tx *gorm.DB) error {
+		token, err := ConsumeOneTimeToken(tx, rawToken, models.TokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", token.UserID).Update("password", hashedPassword).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", token.UserID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return DeletePersonalAccessTokens(tx, token.UserID)
+	})
+}
+
+// tokenInstructions tells the user how to use an emailed token, as a link to
+// the web app when APP_URL is set.
+func tokenInstructions(page string, rawToken string) string {
+	appURL := strings.TrimRight(initializers.Config.Server.AppURL, "/")
+	if appURL == "" {
+		return "Use this token: " + rawToken
+	}
+	return "Open this link: " + appURL + "/" + page + "?token=" + url.QueryEscape(rawToken)
+}
@@ -0,0 +1,119 @@
+package utils
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Waris-Shaik/todo-backend/config"
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// usePasswords switches the password settings for the rest of the test.
+func usePasswords(t *testing.T, passwords config.Passwords) {
+	t.Helper()
+	previous := initializers.Config.Passwords
+	initializers.Config.Passwords = passwords
+	t.Cleanup(func() { initializers.Config.Passwords = previous })
+}
+
+// cheapArgon2 hashes with the lowest argon2id cost, to keep the tests fast.
+var cheapArgon2 = config.Passwords{Hasher: "argon2id", Argon2Memory: 64, Argon2Iterations: 1, Argon2Parallelism: 1, BcryptCost: bcrypt.MinCost}
+
+func TestArgon2Hash(t *testing.T) {
+	usePasswords(t, cheapArgon2)
+
+	hash, err := HashPassword("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=64,t=1,p=1$") {
+		t.Fatalf("hash %q is not in the PHC format", hash)
+	}
+	params, salt, key, err := decodeArgon2Hash(hash)
+	if err != nil || params != (argon2Params{Memory: 64, Iterations: 1, Parallelism: 1}) || len(salt) != argon2SaltLength || len(key) != argon2KeyLength {
+		t.Fatalf("decoded %+v with a %d byte salt and %d byte key, %v", params, len(salt), len(key), err)
+	}
+	if comparePassword(hash, "correct horse battery staple") != nil || comparePassword(hash, "correct horse battery stapler") == nil {
+		t.Fatal("hash does not check the password")
+	}
+
+	// Hashes made elsewhere with other settings are read from their PHC string
+	salt = []byte("somesaltsomesalt")
+	key = argon2.IDKey([]byte("password"), salt, 2, 32, 4, 16)
+	hash = fmt.Sprintf("$argon2id$v=19$m=32,t=2,p=4$%s$%s", base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+	if err := comparePassword(hash, "password"); err != nil {
+		t.Fatalf("compare hash with other settings: %v", err)
+	}
+
+	for _, invalid := range []string{
+		"$argon2i$v=19$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=16$m=64,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA",
+		"$argon2id$v=19$m=64,t=1,p=1$!!!$a2V5",
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$",
+	} {
+		if _, _, _, err := decodeArgon2Hash(invalid); err == nil {
+			t.Errorf("decoded invalid hash %q", invalid)
+		}
+	}
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	usePasswords(t, cheapArgon2)
+	argon2Hash, _ := HashPassword("password")
+
+	usePasswords(t, config.Passwords{Hasher: "bcrypt", BcryptCost: bcrypt.MinCost})
+	bcryptHash, _ := HashPassword("password")
+
+	stronger := cheapArgon2
+	stronger.Argon2Iterations = 2
+	tests := []struct {
+		name      string
+		passwords config.Passwords
+		hash      string
+		want      bool
+	}{
+		{"argon2id with the same settings", cheapArgon2, argon2Hash, false},
+		{"argon2id with weaker settings", stronger, argon2Hash, true},
+		{"bcrypt when hashing with argon2id", cheapArgon2, bcryptHash, true},
+		{"bcrypt with the same cost", config.Passwords{Hasher: "bcrypt", BcryptCost: bcrypt.MinCost}, bcryptHash, false},
+		{"bcrypt with another cost", config.Passwords{Hasher: "bcrypt", BcryptCost: bcrypt.MinCost + 1}, bcryptHash, true},
+		{"argon2id when hashing with bcrypt", config.Passwords{Hasher: "bcrypt", BcryptCost: bcrypt.MinCost}, argon2Hash, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			usePasswords(t, test.passwords)
+			if got := PasswordNeedsRehash(test.hash); got != test.want {
+				t.Fatalf("PasswordNeedsRehash = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestBcryptPasswordLength(t *testing.T) {
+	usePasswords(t, config.Passwords{Hasher: "bcrypt", BcryptCost: bcrypt.MinCost})
+
+	// bcrypt ignores what follows the first 72 bytes, so longer passwords are
+	// refused rather than silently cut
+	if err := ValidatePassword(&models.User{Password: strings.Repeat("a", 72)}); err != nil {
+		t.Fatalf("72 byte password refused: %v", err)
+	}
+	if err := ValidatePassword(&models.User{Password: strings.Repeat("a", 73)}); err == nil || !strings.Contains(err.Error(), "72 bytes") {
+		t.Fatalf("73 byte password: %v, want it refused", err)
+	}
+	if err := ValidatePassword(&models.User{Password: strings.Repeat("é", 40)}); err == nil {
+		t.Fatal("40 character password of 80 bytes was accepted")
+	}
+
+	usePasswords(t, cheapArgon2)
+	if err := ValidatePassword(&models.User{Password: strings.Repeat("a", 73)}); err != nil {
+		t.Fatalf("73 byte password refused with argon2id: %v", err)
+	}
+}
@@ -0,0 +1,110 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidAccessToken = errors.New("invalid or expired access token")
+
+// tokenTouchInterval is how stale last_used_at may get before a request
+// updates it.
+const tokenTouchInterval = time.Minute
+
+// CreatePersonalAccessToken stores a new token for the user and returns it
+// along with the raw token, which is not recoverable afterwards.
+func CreatePersonalAccessToken(userID uint, name string, scopes []string, expiresAt *time.Time) (models.PersonalAccessToken, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return models.PersonalAccessToken{}, "", fmt.Errorf("token name is required")
+	}
+
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return models.PersonalAccessToken{}, "", err
+	}
+
+	random, err := NewRandomToken()
+	if err != nil {
+		return models.PersonalAccessToken{}, "", err
+	}
+	rawToken := models.PersonalAccessTokenPrefix + random
+
+	token := models.PersonalAccessToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    rawToken[:len(models.PersonalAccessTokenPrefix)+6],
+		TokenHash: HashToken(rawToken),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := initializers.DB.Create(&token).Error; err != nil {
+		return models.PersonalAccessToken{}, "", err
+	}
+	return token, rawToken, nil
+}
+
+// FindPersonalAccessToken looks up an unexpired token by its raw value and
+// records that it was used.
+func FindPersonalAccessToken(rawToken string) (models.PersonalAccessToken, error) {
+	var token models.PersonalAccessToken
+	if err := initializers.DB.Where("token_hash = ?", HashToken(rawToken)).First(&token).Error; err != nil {
+		return token, ErrInvalidAccessToken
+	}
+	if token.ExpiresAt != nil && time.Now().After(*token.ExpiresAt) {
+		return token, ErrInvalidAccessToken
+	}
+
+	if token.LastUsedAt == nil || time.Since(*token.LastUsedAt) >= tokenTouchInterval {
+		now := time.Now()
+		initializers.DB.Model(&token).Update("last_used_at", now)
+		token.LastUsedAt = &now
+	}
+	return token, nil
+}
+
+// DeletePersonalAccessTokens revokes every token the user holds, when their
+// password is reset or their account is disabled.
+func DeletePersonalAccessTokens(db *gorm.DB, userID uint) error {
+	return db.Where("user_id = ?", userID).Delete(&models.PersonalAccessToken{}).Error
+}
+
+// HasScope reports whether the granted scopes allow required. Admin allows
+// everything and write access to todos includes read access.
+func HasScope(granted []string, required string) bool {
+	for _, scope := range granted {
+		if scope == required || scope == models.ScopeAdmin {
+			return true
+		}
+		if scope == models.ScopeTodosWrite && required == models.ScopeTodosRead {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeScopes(scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+
+	seen := map[string]bool{}
+	normalized := []string{}
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !models.IsValidScope(scope) {
+			return nil, fmt.Errorf("unknown scope %q, must be one of todos:read, todos:write, admin", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
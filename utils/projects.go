@@ -0,0 +1,75 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"gorm.io/gorm"
+)
+
+var ErrProjectNotFound = errors.New("project not found")
+
+// FindProjectForUser loads one of the user's projects.
+func FindProjectForUser(projectID interface{}, userID uint) (models.Project, error) {
+	var project models.Project
+	result := initializers.DB.Where("id = ? AND user_id = ?", projectID, userID).First(&project)
+	if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+		return project, ErrProjectNotFound
+	}
+	return project, result.Error
+}
+
+// ValidateTodoProject checks that a todo owned by ownerID can be put in the
+// project: it must belong to the same user and not be archived.
+func ValidateTodoProject(projectID uint, ownerID uint) error {
+	project, err := FindProjectForUser(projectID, ownerID)
+	if err != nil {
+		return err
+	}
+	if project.Archived {
+		return fmt.Errorf("project is archived")
+	}
+	return nil
+}
+
+// LoadProjectCounts fills in how many open and completed top-level todos each
+// project holds.
+func LoadProjectCounts(projects []models.Project) error {
+	if len(projects) == 0 {
+		return nil
+	}
+
+	ids := make([]uint, len(projects))
+	for i, project := range projects {
+		ids[i] = project.ID
+	}
+
+	var counts []struct {
+		ProjectID uint
+		Completed bool
+		Count     int64
+	}
+	result := initializers.DB.Model(&models.Todo{}).
+		Select("project_id, completed, COUNT(*) AS count").
+		Where("project_id IN ? AND parent_id IS NULL", ids).
+		Group("project_id, completed").
+		Scan(&counts)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	byID := make(map[uint]*models.Project, len(projects))
+	for i := range projects {
+		byID[projects[i].ID] = &projects[i]
+	}
+	for _, count := range counts {
+		if count.Completed {
+			byID[count.ProjectID].CompletedCount = count.Count
+		} else {
+			byID[count.ProjectID].OpenCount = count.Count
+		}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/models"
+)
+
+// ValidateRecurrence checks a todo's recurrence rule against its due date and
+// returns the rule in normalized form. Recurring todos need a due date, which
+// is the first occurrence of the series.
+func ValidateRecurrence(recurrence string, dueAt *time.Time, location *time.Location) (string, error) {
+	if recurrence == "" {
+		return "", nil
+	}
+	rule, err := ParseRecurrenceRule(recurrence, location)
+	if err != nil {
+		return "", fmt.Errorf("recurrence: %v", err)
+	}
+	if dueAt == nil {
+		return "", fmt.Errorf("recurring todos need a due_at")
+	}
+	return rule.String(), nil
+}
+
+// NextOccurrence returns the due date of the occurrence following the todo,
+// computed in its owner's time zone, or false when the series is over.
+func NextOccurrence(todo models.Todo, location *time.Location) (time.Time, bool, error) {
+	if todo.Recurrence == "" || todo.DueAt == nil {
+		return time.Time{}, false, nil
+	}
+	rule, err := ParseRecurrenceRule(todo.Recurrence, location)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	next, ok := rule.Next(*todo.DueAt, max(todo.Occurrence, 1), location)
+	return next, ok, nil
+}
+
+// ShiftReminder moves the todo's reminder along with its due date, keeping the
+// same lead time.
+func ShiftReminder(todo models.Todo, dueAt time.Time) *time.Time {
+	if todo.RemindAt == nil || todo.DueAt == nil {
+		return nil
+	}
+	remindAt := dueAt.Add(todo.RemindAt.Sub(*todo.DueAt))
+	return &remindAt
+}
+
+// NextOccurrenceTodo builds the todo following a completed occurrence of a
+// recurring series, for TodoRepository.SetCompleted to create. It returns nil
+// when the series is over or the next occurrence already exists. Occurrences
+// follow the owner's time zone.
+func NextOccurrenceTodo(todo models.Todo, owner models.User) (*models.Todo, error) {
+	if todo.Recurrence == "" || todo.NextOccurrenceID != nil {
+		return nil, nil
+	}
+
+	dueAt, ok, err := NextOccurrence(todo, owner.Location())
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	seriesID := todo.ID
+	if todo.SeriesID != nil {
+		seriesID = *todo.SeriesID
+	}
+
+	return &models.Todo{
+		Title:       todo.Title,
+		Description: todo.Description,
+		Priority:    todo.Priority,
+		DueAt:       &dueAt,
+		RemindAt:    ShiftReminder(todo, dueAt),
+		Recurrence:  todo.Recurrence,
+		Occurrence:  max(todo.Occurrence, 1) + 1,
+		SeriesID:    &seriesID,
+		UserID:      todo.UserID,
+		ProjectID:   todo.ProjectID,
+		ParentID:    todo.ParentID,
+		Position:    todo.Position,
+	}, nil
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/Waris-Shaik/todo-backend/models"
+)
+
+func TestNextOccurrence(t *testing.T) {
+	location := mustLocation(t, "America/New_York")
+	dueAt := mustTime(t, "2026-03-07T14:00:00Z")
+
+	// Todos count their occurrences from 1, and 0 is read as the first
+	next, ok, err := NextOccurrence(models.Todo{Recurrence: "FREQ=DAILY;COUNT=2", DueAt: &dueAt}, location)
+	if err != nil || !ok || !next.Equal(mustTime(t, "2026-03-08T13:00:00Z")) {
+		t.Fatalf("NextOccurrence = %v, %v, %v, want 09:00 local on the 8th", next, ok, err)
+	}
+	if _, ok, _ := NextOccurrence(models.Todo{Recurrence: "FREQ=DAILY;COUNT=2", DueAt: &next, Occurrence: 2}, location); ok {
+		t.Fatal("NextOccurrence went past COUNT")
+	}
+	if _, ok, _ := NextOccurrence(models.Todo{Recurrence: "FREQ=DAILY"}, location); ok {
+		t.Fatal("NextOccurrence found an occurrence for a todo without a due date")
+	}
+}
@@ -0,0 +1,277 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRecurrencePeriods bounds how many periods are scanned when looking for
+// the next occurrence, so a rule that can never match does not loop forever.
+const maxRecurrencePeriods = 5000
+
+var rruleWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+// RecurrenceRule is the subset of an RFC 5545 RRULE supported for todos:
+// FREQ, INTERVAL, BYDAY, COUNT and UNTIL. Weeks start on Monday.
+type RecurrenceRule struct {
+	Freq     string
+	Interval int
+	ByDay    []RecurrenceDay
+	Count    int        // Total number of occurrences, 0 when unbounded
+	Until    *time.Time // Last instant an occurrence may fall on
+}
+
+// RecurrenceDay is a BYDAY entry. Ordinal picks the nth (or nth from last
+// when negative) such weekday of the month and is only allowed with
+// FREQ=MONTHLY; 0 means every such weekday.
+type RecurrenceDay struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+// ParseRecurrenceRule parses an RRULE such as
+// "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH;COUNT=10". The "RRULE:" prefix is
+// optional. A date-only or floating UNTIL is read in location.
+func ParseRecurrenceRule(value string, location *time.Location) (RecurrenceRule, error) {
+	rule := RecurrenceRule{Interval: 1}
+
+	value = strings.TrimPrefix(strings.TrimSpace(value), "RRULE:")
+	if value == "" {
+		return rule, fmt.Errorf("recurrence rule is empty")
+	}
+
+	for _, part := range strings.Split(value, ";") {
+		name, val, ok := strings.Cut(part, "=")
+		if !ok || val == "" {
+			return rule, fmt.Errorf("invalid recurrence rule part %q", part)
+		}
+
+		switch strings.ToUpper(name) {
+		case "FREQ":
+			rule.Freq = strings.ToUpper(val)
+			if rule.Freq != "DAILY" && rule.Freq != "WEEKLY" && rule.Freq != "MONTHLY" && rule.Freq != "YEARLY" {
+				return rule, fmt.Errorf("FREQ must be DAILY, WEEKLY, MONTHLY or YEARLY")
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(val)
+			if err != nil || interval < 1 {
+				return rule, fmt.Errorf("INTERVAL must be a positive number")
+			}
+			rule.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(val)
+			if err != nil || count < 1 {
+				return rule, fmt.Errorf("COUNT must be a positive number")
+			}
+			rule.Count = count
+		case "UNTIL":
+			until, err := parseRRuleUntil(val, location)
+			if err != nil {
+				return rule, err
+			}
+			rule.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(val), ",") {
+				if len(day) < 2 {
+					return rule, fmt.Errorf("invalid BYDAY value %q", day)
+				}
+				weekday, ok := rruleWeekdays[day[len(day)-2:]]
+				if !ok {
+					return rule, fmt.Errorf("invalid BYDAY value %q", day)
+				}
+				ordinal := 0
+				if prefix := day[:len(day)-2]; prefix != "" {
+					parsed, err := strconv.Atoi(prefix)
+					if err != nil || parsed == 0 || parsed < -5 || parsed > 5 {
+						return rule, fmt.Errorf("invalid BYDAY value %q", day)
+					}
+					ordinal = parsed
+				}
+				rule.ByDay = append(rule.ByDay, RecurrenceDay{Ordinal: ordinal, Weekday: weekday})
+			}
+		default:
+			return rule, fmt.Errorf("unsupported recurrence rule part %q", name)
+		}
+	}
+
+	if rule.Freq == "" {
+		return rule, fmt.Errorf("FREQ is required")
+	}
+	if rule.Count > 0 && rule.Until != nil {
+		return rule, fmt.Errorf("COUNT and UNTIL cannot be used together")
+	}
+	for _, day := range rule.ByDay {
+		if day.Ordinal != 0 && rule.Freq != "MONTHLY" {
+			return rule, fmt.Errorf("BYDAY with a number is only supported with FREQ=MONTHLY")
+		}
+	}
+	if rule.Freq == "YEARLY" && len(rule.ByDay) > 0 {
+		return rule, fmt.Errorf("BYDAY is not supported with FREQ=YEARLY")
+	}
+
+	return rule, nil
+}
+
+// String formats the rule back into RRULE syntax.
+func (rule RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + rule.Freq}
+	if rule.Interval > 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(rule.Interval))
+	}
+	if len(rule.ByDay) > 0 {
+		days := make([]string, len(rule.ByDay))
+		for i, day := range rule.ByDay {
+			days[i] = strings.ToUpper(day.Weekday.String()[:2])
+			if day.Ordinal != 0 {
+				days[i] = strconv.Itoa(day.Ordinal) + days[i]
+			}
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if rule.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(rule.Count))
+	}
+	if rule.Until != nil {
+		parts = append(parts, "UNTIL="+rule.Until.UTC().Format("20060102T150405Z"))
+	}
+	return strings.Join(parts, ";")
+}
+
+// Next returns the occurrence that follows current, the occurrence numbered
+// index (starting at 1) of the series. Days are counted in location so that
+// occurrences keep their local time of day across DST changes. It reports
+// false when the series is over.
+func (rule RecurrenceRule) Next(current time.Time, index int, location *time.Location) (time.Time, bool) {
+	if rule.Count > 0 && index >= rule.Count {
+		return time.Time{}, false
+	}
+
+	local := current.In(location)
+	for period := 0; period < maxRecurrencePeriods; period++ {
+		for _, candidate := range rule.candidates(local, period*rule.Interval) {
+			if !candidate.After(local) {
+				continue
+			}
+			if rule.Until != nil && candidate.After(*rule.Until) {
+				return time.Time{}, false
+			}
+			return candidate.UTC(), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Occurrences lists up to n occurrences following current.
+func (rule RecurrenceRule) Occurrences(current time.Time, index, n int, location *time.Location) []time.Time {
+	occurrences := []time.Time{}
+	for len(occurrences) < n {
+		next, ok := rule.Next(current, index, location)
+		if !ok {
+			break
+		}
+		occurrences = append(occurrences, next)
+		current, index = next, index+1
+	}
+	return occurrences
+}
+
+// candidates returns, in order, the instants the rule produces in the period
+// that lies offset periods after the one containing start.
+func (rule RecurrenceRule) candidates(start time.Time, offset int) []time.Time {
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+	}
+	matchesDay := func(weekday time.Weekday) bool {
+		if len(rule.ByDay) == 0 {
+			return true
+		}
+		for _, day := range rule.ByDay {
+			if day.Weekday == weekday {
+				return true
+			}
+		}
+		return false
+	}
+
+	var candidates []time.Time
+	switch rule.Freq {
+	case "DAILY":
+		day := at(start.Year(), start.Month(), start.Day()+offset)
+		if matchesDay(day.Weekday()) {
+			candidates = append(candidates, day)
+		}
+
+	case "WEEKLY":
+		// Weeks start on Monday
+		monday := at(start.Year(), start.Month(), start.Day()-(int(start.Weekday())+6)%7+7*offset)
+		if len(rule.ByDay) == 0 {
+			candidates = append(candidates, monday.AddDate(0, 0, (int(start.Weekday())+6)%7))
+		}
+		for i := 0; i < 7 && len(rule.ByDay) > 0; i++ {
+			day := monday.AddDate(0, 0, i)
+			if matchesDay(day.Weekday()) {
+				candidates = append(candidates, day)
+			}
+		}
+
+	case "MONTHLY":
+		first := at(start.Year(), start.Month()+time.Month(offset), 1)
+		daysInMonth := first.AddDate(0, 1, -1).Day()
+		if len(rule.ByDay) == 0 {
+			// Months without the day of the month are skipped
+			if start.Day() <= daysInMonth {
+				candidates = append(candidates, at(first.Year(), first.Month(), start.Day()))
+			}
+			break
+		}
+		for day := 1; day <= daysInMonth; day++ {
+			date := at(first.Year(), first.Month(), day)
+			for _, byDay := range rule.ByDay {
+				if byDay.Weekday != date.Weekday() {
+					continue
+				}
+				fromStart, fromEnd := (day-1)/7+1, -((daysInMonth-day)/7 + 1)
+				if byDay.Ordinal == 0 || byDay.Ordinal == fromStart || byDay.Ordinal == fromEnd {
+					candidates = append(candidates, date)
+					break
+				}
+			}
+		}
+
+	case "YEARLY":
+		// Years without the date, such as February 29th, are skipped
+		date := at(start.Year()+offset, start.Month(), start.Day())
+		if date.Month() == start.Month() {
+			candidates = append(candidates, date)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].Before(candidates[j]) })
+	return candidates
+}
+
+// parseRRuleUntil parses an UNTIL value. UTC values end in Z; floating values
+// and dates are read in location, a date meaning the end of that day.
+func parseRRuleUntil(value string, location *time.Location) (time.Time, error) {
+	if until, err := time.Parse("20060102T150405Z", value); err == nil {
+		return until, nil
+	}
+	if until, err := time.ParseInLocation("20060102T150405", value, location); err == nil {
+		return until, nil
+	}
+	if until, err := time.ParseInLocation("20060102", value, location); err == nil {
+		return until.AddDate(0, 0, 1).Add(-time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("UNTIL must look like 20261231 or 20261231T235959Z")
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata"
+)
+
+func mustTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return location
+}
+
+func TestRecurrenceRuleNext(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		location string
+		current  string
+		index    int
+		want     string // Empty when the series is over
+	}{
+		{"31st skips short months", "FREQ=MONTHLY", "UTC", "2026-01-31T09:00:00Z", 1, "2026-03-31T09:00:00Z"},
+		{"31st after March", "FREQ=MONTHLY", "UTC", "2026-03-31T09:00:00Z", 2, "2026-05-31T09:00:00Z"},
+		{"29th in a leap year", "FREQ=MONTHLY", "UTC", "2024-01-29T09:00:00Z", 1, "2024-02-29T09:00:00Z"},
+		{"29th in a common year", "FREQ=MONTHLY", "UTC", "2025-01-29T09:00:00Z", 1, "2025-03-29T09:00:00Z"},
+		{"leap day waits for the next leap year", "FREQ=YEARLY", "UTC", "2024-02-29T09:00:00Z", 1, "2028-02-29T09:00:00Z"},
+		{"last Friday", "FREQ=MONTHLY;BYDAY=-1FR", "UTC", "2026-01-30T09:00:00Z", 1, "2026-02-27T09:00:00Z"},
+		{"last Friday of a month with five", "FREQ=MONTHLY;BYDAY=-1FR", "UTC", "2026-04-24T09:00:00Z", 1, "2026-05-29T09:00:00Z"},
+		{"second Tuesday", "FREQ=MONTHLY;BYDAY=2TU", "UTC", "2026-01-13T09:00:00Z", 1, "2026-02-10T09:00:00Z"},
+		{"within the week", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", "UTC", "2026-01-05T09:00:00Z", 1, "2026-01-08T09:00:00Z"},
+		{"skips the off week", "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH", "UTC", "2026-01-08T09:00:00Z", 2, "2026-01-19T09:00:00Z"},
+		{"before COUNT", "FREQ=DAILY;COUNT=3", "UTC", "2026-01-02T09:00:00Z", 2, "2026-01-03T09:00:00Z"},
+		{"at COUNT", "FREQ=DAILY;COUNT=3", "UTC", "2026-01-03T09:00:00Z", 3, ""},
+		{"on the UNTIL date", "FREQ=DAILY;UNTIL=20260110", "UTC", "2026-01-09T09:00:00Z", 1, "2026-01-10T09:00:00Z"},
+		{"past the UNTIL date", "FREQ=DAILY;UNTIL=20260110", "UTC", "2026-01-10T09:00:00Z", 2, ""},
+		{"on the UNTIL instant", "FREQ=DAILY;UNTIL=20260110T090000Z", "UTC", "2026-01-09T09:00:00Z", 1, "2026-01-10T09:00:00Z"},
+		{"past the UNTIL instant", "FREQ=DAILY;UNTIL=20260110T085959Z", "UTC", "2026-01-09T09:00:00Z", 1, ""},
+		{"across the spring DST change", "FREQ=DAILY", "America/New_York", "2026-03-07T14:00:00Z", 1, "2026-03-08T13:00:00Z"},
+		{"across the autumn DST change", "FREQ=WEEKLY", "Europe/Berlin", "2026-10-19T07:00:00Z", 1, "2026-10-26T08:00:00Z"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			location := mustLocation(t, test.location)
+			rule, err := ParseRecurrenceRule(test.rule, location)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			next, ok := rule.Next(mustTime(t, test.current), test.index, location)
+			if test.want == "" {
+				if ok {
+					t.Fatalf("Next = %v, want the series to be over", next)
+				}
+				return
+			}
+			if want := mustTime(t, test.want); !ok || !next.Equal(want) {
+				t.Fatalf("Next = %v, %v, want %v", next, ok, want)
+			}
+		})
+	}
+}
+
+func TestRecurrenceRuleOccurrences(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		location string
+		current  string
+		n        int
+		want     []string
+	}{
+		{"31st", "FREQ=MONTHLY", "UTC", "2026-01-31T09:00:00Z", 4,
+			[]string{"2026-03-31T09:00:00Z", "2026-05-31T09:00:00Z", "2026-07-31T09:00:00Z", "2026-08-31T09:00:00Z"}},
+		{"leap day", "FREQ=YEARLY", "UTC", "2024-02-29T09:00:00Z", 2,
+			[]string{"2028-02-29T09:00:00Z", "2032-02-29T09:00:00Z"}},
+		{"last Friday", "FREQ=MONTHLY;BYDAY=-1FR", "UTC", "2026-01-30T09:00:00Z", 3,
+			[]string{"2026-02-27T09:00:00Z", "2026-03-27T09:00:00Z", "2026-04-24T09:00:00Z"}},
+		{"COUNT", "FREQ=DAILY;COUNT=4", "UTC", "2026-01-01T09:00:00Z", 10,
+			[]string{"2026-01-02T09:00:00Z", "2026-01-03T09:00:00Z", "2026-01-04T09:00:00Z"}},
+		{"UNTIL", "FREQ=DAILY;UNTIL=20260105", "UTC", "2026-01-01T09:00:00Z", 10,
+			[]string{"2026-01-02T09:00:00Z", "2026-01-03T09:00:00Z", "2026-01-04T09:00:00Z", "2026-01-05T09:00:00Z"}},
+		{"UNTIL in the owner's time zone", "FREQ=DAILY;UNTIL=20260102", "Asia/Tokyo", "2026-01-01T00:00:00Z", 10,
+			[]string{"2026-01-02T00:00:00Z"}},
+		{"spring DST change", "FREQ=DAILY", "America/New_York", "2026-03-07T14:00:00Z", 3,
+			[]string{"2026-03-08T13:00:00Z", "2026-03-09T13:00:00Z", "2026-03-10T13:00:00Z"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			location := mustLocation(t, test.location)
+			rule, err := ParseRecurrenceRule(test.rule, location)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			occurrences := rule.Occurrences(mustTime(t, test.current), 1, test.n, location)
+			if len(occurrences) != len(test.want) {
+				t.Fatalf("Occurrences = %v, want %v", occurrences, test.want)
+			}
+			for i, want := range test.want {
+				if !occurrences[i].Equal(mustTime(t, want)) {
+					t.Fatalf("Occurrences = %v, want %v", occurrences, test.want)
+				}
+			}
+		})
+	}
+}
@@ -1,40 +1,81 @@
 package utils
 
 import (
-	"fmt"
+	"crypto/subtle"
 	"net/http"
-	"os"
+	"strings"
 	"time"
 
-	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/initializers"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 )
 
-func SendCookie(user *models.User, ctx *gin.Context) (*jwt.Token, error) {
+// refreshCookiePath limits the refresh token cookie to the user routes that
+// need it, so it is not sent along with every API call.
+const refreshCookiePath = "/api/v1/users"
 
-	// Generate a jwt
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"_id": user.ID,
-		"exp": time.Now().Add(time.Minute * 30).Unix(), // Token expiration time
-	})
+// oidcStateCookie ties an OIDC login to the browser that started it, and is
+// only sent back to the OIDC routes.
+const (
+	oidcStateCookie     = "oidc_state"
+	oidcStateCookiePath = "/api/v1/auth/oidc"
+)
 
-	// Get JWT secret key
-	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
-	if len(secretKey) == 0 {
-		return nil, fmt.Errorf("jwt secret key not found")
-	}
+// SendCookie sets the access and refresh token cookies.
+func SendCookie(ctx *gin.Context, accessToken string, refreshToken string) {
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie("token", accessToken, int(AccessTokenTTL().Seconds()), "", "", false, true)
+	ctx.SetCookie("refresh_token", refreshToken, int(RefreshTokenTTL().Seconds()), refreshCookiePath, "", false, true)
+}
 
-	// Sign and get the encoded token as a string usig the jwt_secret
-	tokenString, err := token.SignedString(secretKey)
+// ClearCookies removes the access and refresh token cookies.
+func ClearCookies(ctx *gin.Context) {
+	ctx.SetCookie("token", "", -1, "", "", false, true)
+	ctx.SetCookie("refresh_token", "", -1, refreshCookiePath, "", false, true)
+}
+
+// SendOIDCStateCookie remembers the state of an OIDC login in the browser
+// for as long as the login request is valid.
+func SendOIDCStateCookie(ctx *gin.Context, state string) {
+	ctx.SetSameSite(http.SameSiteLaxMode)
+	ctx.SetCookie(oidcStateCookie, state, int(oidcStateTTL.Seconds()), oidcStateCookiePath, "", false, true)
+}
 
-	if err != nil {
-		return nil, fmt.Errorf("failed to create token")
+// CheckOIDCStateCookie returns ErrInvalidOIDCState unless the browser's state
+// cookie matches the state the provider sent back. The cookie is cleared
+// either way, each login uses it once.
+func CheckOIDCStateCookie(ctx *gin.Context, state string) error {
+	cookie, err := ctx.Cookie(oidcStateCookie)
+	ctx.SetCookie(oidcStateCookie, "", -1, oidcStateCookiePath, "", false, true)
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie), []byte(state)) != 1 {
+		return ErrInvalidOIDCState
 	}
+	return nil
+}
 
-	ctx.SetSameSite(http.SameSiteLaxMode)
-	ctx.SetCookie("token", tokenString, int((time.Minute * 30).Seconds()), "", "", false, true)
-	fmt.Println("user.ID is", user.ID)
+// AccessTokenTTL returns how long access tokens are valid, set with
+// ACCESS_TOKEN_TTL.
+func AccessTokenTTL() time.Duration {
+	return initializers.Config.Auth.AccessTokenTTL
+}
+
+// RefreshTokenTTL returns how long refresh tokens are valid, set with
+// REFRESH_TOKEN_TTL.
+func RefreshTokenTTL() time.Duration {
+	return initializers.Config.Auth.RefreshTokenTTL
+}
+
+// RequestToken returns the token the request authenticates with, taken from
+// an "Authorization: Bearer" header or else the token cookie.
+func RequestToken(ctx *gin.Context) string {
+	if header := ctx.GetHeader("Authorization"); header != "" {
+		scheme, token, found := strings.Cut(header, " ")
+		if found && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+		return ""
+	}
 
-	return token, nil
+	token, _ := ctx.Cookie("token")
+	return token
 }
@@ -0,0 +1,229 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token please login")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used, session revoked please login")
+	ErrAccountDisabled     = errors.New("account is disabled, please contact support")
+)
+
+// SessionTokens is the access and refresh token pair handed out on login and
+// on every refresh.
+type SessionTokens struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	SessionID    uint   `json:"-"`
+}
+
+// HashToken returns the hex SHA-256 of a token, which is what gets stored.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewRandomToken returns 32 random bytes encoded as base64url.
+func NewRandomToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// sessionTouchInterval is how stale last_seen_at may get before a request
+// updates it, so busy clients do not write on every call.
+const sessionTouchInterval = time.Minute
+
+// StartSession creates a new session for the user, recording the device it
+// was started from, and issues its first access and refresh tokens.
+func StartSession(ctx *gin.Context, user *models.User) (SessionTokens, error) {
+	var tokens SessionTokens
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		session := models.Session{
+			UserID:     user.ID,
+			UserAgent:  ctx.Request.UserAgent(),
+			IP:         ctx.ClientIP(),
+			LastSeenAt: time.Now(),
+		}
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		var err error
+		tokens, err = issueTokens(tx, user, session.ID)
+		return err
+	})
+	return tokens, err
+}
+
+// RotateRefreshToken exchanges a refresh token for a new token pair. Each
+// refresh token works once; presenting a used one revokes its whole session
+// since either the client or an attacker holds a stolen copy.
+func RotateRefreshToken(ctx *gin.Context, rawToken string) (models.User, SessionTokens, error) {
+	var user models.User
+	var tokens SessionTokens
+
+	var refreshToken models.RefreshToken
+	if err := initializers.DB.Where("token_hash = ?", HashToken(rawToken)).First(&refreshToken).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return user, tokens, ErrInvalidRefreshToken
+		}
+		return user, tokens, err
+	}
+
+	if refreshToken.UsedAt != nil {
+		if err := RevokeSession(refreshToken.SessionID); err != nil {
+			return user, tokens, err
+		}
+		return user, tokens, ErrRefreshTokenReused
+	}
+
+	var session models.Session
+	if err := initializers.DB.First(&session, refreshToken.SessionID).Error; err != nil || session.RevokedAt != nil {
+		return user, tokens, ErrInvalidRefreshToken
+	}
+	if time.Now().After(refreshToken.ExpiresAt) {
+		return user, tokens, ErrInvalidRefreshToken
+	}
+
+	if err := initializers.DB.First(&user, refreshToken.UserID).Error; err != nil {
+		return user, tokens, ErrInvalidRefreshToken
+	}
+	if user.DisabledAt != nil {
+		return user, tokens, ErrAccountDisabled
+	}
+
+	err := initializers.DB.Transaction(func(tx *gorm.DB) error {
+		// Mark the token used only if nobody beat us to it, so two concurrent
+		// refreshes with the same token cannot both succeed
+		result := tx.Model(&models.RefreshToken{}).
+			Where("id = ? AND used_at IS NULL", refreshToken.ID).
+			Update("used_at", time.Now())
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrRefreshTokenReused
+		}
+
+		// A refresh is activity from the session's device
+		if err := tx.Model(&session).Updates(map[string]interface{}{
+			"user_agent":   ctx.Request.UserAgent(),
+			"ip":           ctx.ClientIP(),
+			"last_seen_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		var err error
+		tokens, err = issueTokens(tx, &user, session.ID)
+		return err
+	})
+	if errors.Is(err, ErrRefreshTokenReused) {
+		if revokeErr := RevokeSession(session.ID); revokeErr != nil {
+			return user, tokens, revokeErr
+		}
+	}
+	return user, tokens, err
+}
+
+// RevokeSession signs a session out. Its access tokens are rejected from now
+// on and its refresh tokens can no longer be rotated.
+func RevokeSession(sessionID uint) error {
+	return initializers.DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// SessionFromRefreshToken returns the session a refresh token belongs to.
+func SessionFromRefreshToken(rawToken string) (uint, bool) {
+	var refreshToken models.RefreshToken
+	if err := initializers.DB.Where("token_hash = ?", HashToken(rawToken)).First(&refreshToken).Error; err != nil {
+		return 0, false
+	}
+	return refreshToken.SessionID, true
+}
+
+// RevokeUserSessions signs the user out of every session.
+func RevokeUserSessions(userID uint) error {
+	return initializers.DB.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeOtherSessions signs the user out of every session except keepID and
+// returns how many were revoked.
+func RevokeOtherSessions(userID uint, keepID uint) (int64, error) {
+	result := initializers.DB.Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected, result.Error
+}
+
+// ActiveSession returns the session if it exists for the user and has not
+// been revoked.
+func ActiveSession(sessionID uint, userID uint) (models.Session, bool) {
+	var session models.Session
+	result := initializers.DB.Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).First(&session)
+	return session, result.Error == nil
+}
+
+// TouchSession records activity on a session, at most once per
+// sessionTouchInterval.
+func TouchSession(ctx *gin.Context, session models.Session) {
+	if time.Since(session.LastSeenAt) < sessionTouchInterval {
+		return
+	}
+	initializers.DB.Model(&session).Updates(map[string]interface{}{
+		"ip":           ctx.ClientIP(),
+		"last_seen_at": time.Now(),
+	})
+}
+
+// ActiveSessions lists the user's sessions that are neither revoked nor idle
+// longer than a refresh token lives, most recently used first.
+func ActiveSessions(userID uint) ([]models.Session, error) {
+	var sessions []models.Session
+	result := initializers.DB.
+		Where("user_id = ? AND revoked_at IS NULL AND last_seen_at > ?", userID, time.Now().Add(-RefreshTokenTTL())).
+		Order("last_seen_at DESC").
+		Find(&sessions)
+	return sessions, result.Error
+}
+
+func issueTokens(tx *gorm.DB, user *models.User, sessionID uint) (SessionTokens, error) {
+	rawToken, err := NewRandomToken()
+	if err != nil {
+		return SessionTokens{}, err
+	}
+
+	refreshToken := models.RefreshToken{
+		SessionID: sessionID,
+		UserID:    user.ID,
+		TokenHash: HashToken(rawToken),
+		ExpiresAt: time.Now().Add(RefreshTokenTTL()),
+	}
+	if err := tx.Create(&refreshToken).Error; err != nil {
+		return SessionTokens{}, err
+	}
+
+	accessToken, err := GenerateToken(user, sessionID)
+	if err != nil {
+		return SessionTokens{}, err
+	}
+
+	return SessionTokens{AccessToken: accessToken, RefreshToken: rawToken, SessionID: sessionID}, nil
+}
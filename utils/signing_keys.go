@@ -0,0 +1,307 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyringReloadInterval = time.Minute // How often other instances' rotations are picked up
+
+var errUnknownSigningKey = errors.New("token signed with an unknown key")
+
+// SigningAlgorithm returns the algorithm new tokens are signed with, set
+// with JWT_SIGNING_ALG: RS256 (the default), EdDSA, or HS256 to keep signing
+// with JWT_SECRET_KEY.
+func SigningAlgorithm() string {
+	return initializers.Config.Auth.SigningAlgorithm
+}
+
+// KeyRotationInterval returns how long a signing key is used before the next
+// one takes over, set with JWT_KEY_ROTATION_INTERVAL.
+func KeyRotationInterval() time.Duration {
+	return initializers.Config.Auth.KeyRotationInterval
+}
+
+// KeyPublishAhead returns how long a new key is published before it is used,
+// set with JWT_KEY_PUBLISH_AHEAD. It should cover how long other services
+// cache the JWKS.
+func KeyPublishAhead() time.Duration {
+	return initializers.Config.Auth.KeyPublishAhead
+}
+
+// RotateSigningKeys makes sure a signing key of the configured algorithm
+// exists and is replaced once it gets old, and drops keys nothing signed with
+// them can still be valid for. It does nothing while signing with HS256.
+func RotateSigningKeys() error {
+	algorithm := SigningAlgorithm()
+	if algorithm == "HS256" {
+		return nil
+	}
+	if algorithm != "RS256" && algorithm != "EdDSA" {
+		return fmt.Errorf("unsupported JWT_SIGNING_ALG %q", algorithm)
+	}
+
+	now := time.Now()
+	if err := initializers.DB.Where("expires_at IS NOT NULL AND expires_at < ?", now).Delete(&models.SigningKey{}).Error; err != nil {
+		return err
+	}
+
+	var latest models.SigningKey
+	result := initializers.DB.Order("activates_at DESC").Limit(1).Find(&latest)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	switch {
+	case result.RowsAffected == 0:
+		// First key, nobody can have cached an older JWKS yet
+		_, err := createSigningKey(algorithm, now)
+		return err
+	case latest.ActivatesAt.After(now):
+		// The next key is already waiting to take over
+		return nil
+	case latest.Algorithm == algorithm && now.Sub(latest.ActivatesAt) < KeyRotationInterval()-KeyPublishAhead():
+		return nil
+	}
+
+	next, err := createSigningKey(algorithm, now.Add(KeyPublishAhead()))
+	if err != nil {
+		return err
+	}
+
+	// Older keys stay published for as long as their tokens can live
+	expiresAt := next.ActivatesAt.Add(AccessTokenTTL() + mfaTokenTTL + time.Minute)
+	err = initializers.DB.Model(&models.SigningKey{}).
+		Where("id <> ? AND expires_at IS NULL", next.ID).
+		Update("expires_at", expiresAt).Error
+	if err != nil {
+		return err
+	}
+
+	keys.reload()
+	return nil
+}
+
+// CheckSigningKeys reports whether tokens can be signed, which needs an
+// active signing key unless signing with HS256.
+func CheckSigningKeys() error {
+	if SigningAlgorithm() == "HS256" {
+		return nil
+	}
+	_, err := keys.active()
+	return err
+}
+
+// JWKS returns the public keys that tokens may be signed with, as a JSON Web
+// Key Set.
+func JWKS() (map[string]interface{}, error) {
+	if err := keys.load(false); err != nil {
+		return nil, err
+	}
+
+	keys.mu.RLock()
+	defer keys.mu.RUnlock()
+
+	set := make([]map[string]string, 0, len(keys.byKid))
+	for _, key := range keys.ordered {
+		jwk := map[string]string{"kid": key.record.Kid, "use": "sig", "alg": key.record.Algorithm}
+		switch public := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk["kty"] = "RSA"
+			jwk["n"] = base64.RawURLEncoding.EncodeToString(public.N.Bytes())
+			jwk["e"] = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk["kty"] = "OKP"
+			jwk["crv"] = "Ed25519"
+			jwk["x"] = base64.RawURLEncoding.EncodeToString(public)
+		default:
+			continue
+		}
+		set = append(set, jwk)
+	}
+	return map[string]interface{}{"keys": set}, nil
+}
+
+// loadedKey is a parsed signing key.
+type loadedKey struct {
+	record  models.SigningKey
+	private crypto.Signer
+	public  crypto.PublicKey
+	method  jwt.SigningMethod
+}
+
+// keyring caches the signing keys from the database.
+type keyring struct {
+	mu       sync.RWMutex
+	byKid    map[string]*loadedKey
+	ordered  []*loadedKey // Oldest first
+	loadedAt time.Time
+}
+
+var keys = &keyring{}
+
+// active returns the newest key that has started signing.
+func (ring *keyring) active() (*loadedKey, error) {
+	if err := ring.load(false); err != nil {
+		return nil, err
+	}
+
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+
+	now := time.Now()
+	for i := len(ring.ordered) - 1; i >= 0; i-- {
+		if !ring.ordered[i].record.ActivatesAt.After(now) {
+			return ring.ordered[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no active signing key")
+}
+
+// verifier returns the key with kid, reloading once when it is unknown in
+// case another instance just rotated.
+func (ring *keyring) verifier(kid string) (*loadedKey, error) {
+	if err := ring.load(false); err != nil {
+		return nil, err
+	}
+	if key := ring.lookup(kid); key != nil {
+		return key, nil
+	}
+	if err := ring.load(true); err != nil {
+		return nil, err
+	}
+	if key := ring.lookup(kid); key != nil {
+		return key, nil
+	}
+	return nil, errUnknownSigningKey
+}
+
+func (ring *keyring) lookup(kid string) *loadedKey {
+	ring.mu.RLock()
+	defer ring.mu.RUnlock()
+	return ring.byKid[kid]
+}
+
+func (ring *keyring) reload() {
+	ring.mu.Lock()
+	ring.loadedAt = time.Time{}
+	ring.mu.Unlock()
+}
+
+// load reads the keys from the database when the cache is stale. With force
+// it reloads anyway, but still at most every few seconds.
+func (ring *keyring) load(force bool) error {
+	ring.mu.RLock()
+	age := time.Since(ring.loadedAt)
+	ring.mu.RUnlock()
+	if age < keyringReloadInterval && (!force || age < 5*time.Second) {
+		return nil
+	}
+
+	var records []models.SigningKey
+	result := initializers.DB.
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("activates_at ASC").
+		Find(&records)
+	if result.Error != nil {
+		return result.Error
+	}
+
+	byKid := make(map[string]*loadedKey, len(records))
+	ordered := make([]*loadedKey, 0, len(records))
+	for _, record := range records {
+		key, err := parseSigningKey(record)
+		if err != nil {
+			return fmt.Errorf("signing key %s: %w", record.Kid, err)
+		}
+		byKid[record.Kid] = key
+		ordered = append(ordered, key)
+	}
+
+	ring.mu.Lock()
+	ring.byKid, ring.ordered, ring.loadedAt = byKid, ordered, time.Now()
+	ring.mu.Unlock()
+	return nil
+}
+
+func createSigningKey(algorithm string, activatesAt time.Time) (models.SigningKey, error) {
+	var private crypto.Signer
+	var err error
+	switch algorithm {
+	case "RS256":
+		private, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "EdDSA":
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+	kid, err := NewRandomToken()
+	if err != nil {
+		return models.SigningKey{}, err
+	}
+
+	key := models.SigningKey{
+		Kid:         kid[:16],
+		Algorithm:   algorithm,
+		PrivateKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER})),
+		PublicKey:   string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+		ActivatesAt: activatesAt,
+	}
+	if err := initializers.DB.Create(&key).Error; err != nil {
+		return models.SigningKey{}, err
+	}
+
+	keys.reload()
+	return key, nil
+}
+
+func parseSigningKey(record models.SigningKey) (*loadedKey, error) {
+	block, _ := pem.Decode([]byte(record.PrivateKey))
+	if block == nil {
+		return nil, fmt.Errorf("invalid private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key")
+	}
+
+	var method jwt.SigningMethod
+	switch record.Algorithm {
+	case "RS256":
+		method = jwt.SigningMethodRS256
+	case "EdDSA":
+		method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported algorithm %q", record.Algorithm)
+	}
+
+	return &loadedKey{record: record, private: private, public: private.Public(), method: method}, nil
+}
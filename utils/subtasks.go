@@ -0,0 +1,74 @@
+package utils
+
+import (
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+)
+
+// MaxSubtaskDepth is how many levels of subtasks a todo can have.
+const MaxSubtaskDepth = repositories.MaxSubtaskDepth
+
+// TodoAncestorIDs returns the IDs of the todo's parent, grandparent and so on
+// up to the top-level todo.
+func TodoAncestorIDs(todo models.Todo) ([]uint, error) {
+	var ids []uint
+	parentID := todo.ParentID
+	for parentID != nil && len(ids) < MaxSubtaskDepth {
+		ids = append(ids, *parentID)
+
+		var parent models.Todo
+		if result := initializers.DB.Select("id", "parent_id").First(&parent, *parentID); result.Error != nil {
+			return nil, result.Error
+		}
+		parentID = parent.ParentID
+	}
+	return ids, nil
+}
+
+// LoadSubtasks fills in the subtask tree and progress of each todo, one
+// query per level. Tags are loaded for the given user.
+func LoadSubtasks(repo repositories.TodoRepository, todos []models.Todo, userID uint) error {
+	level := make([]*models.Todo, len(todos))
+	for i := range todos {
+		level[i] = &todos[i]
+	}
+
+	for depth := 0; len(level) > 0 && depth < MaxSubtaskDepth; depth++ {
+		ids := make([]uint, len(level))
+		for i, todo := range level {
+			ids[i] = todo.ID
+		}
+
+		// Retreive the subtasks of the whole level at once
+		children, err := repo.Subtasks(ids, userID)
+		if err != nil {
+			return err
+		}
+
+		byParent := make(map[uint][]models.Todo)
+		for _, child := range children {
+			byParent[*child.ParentID] = append(byParent[*child.ParentID], child)
+		}
+
+		var next []*models.Todo
+		for _, todo := range level {
+			todo.Subtasks = byParent[todo.ID]
+			if len(todo.Subtasks) == 0 {
+				continue
+			}
+
+			progress := models.TodoProgress{Total: len(todo.Subtasks)}
+			for i := range todo.Subtasks {
+				if todo.Subtasks[i].Completed {
+					progress.Done++
+				}
+				next = append(next, &todo.Subtasks[i])
+			}
+			todo.Progress = &progress
+		}
+		level = next
+	}
+
+	return nil
+}
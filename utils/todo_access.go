@@ -0,0 +1,97 @@
+package utils
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+)
+
+var ErrTodoNotFound = errors.New("todo not found")
+
+// FindOwnTodoWithTrashed finds a todo of the user by its ID from the URL,
+// whether it is in the trash or not. Shared todos are not returned, only the
+// owner can restore or purge a todo.
+func FindOwnTodoWithTrashed(todos repositories.TodoRepository, todoID string, userID uint) (models.Todo, error) {
+	id, err := strconv.ParseUint(todoID, 10, 64)
+	if err != nil {
+		return models.Todo{}, ErrTodoNotFound
+	}
+
+	todo, err := todos.FindWithTrashed(uint(id))
+	if errors.Is(err, repositories.ErrNotFound) || (err == nil && todo.UserID != userID) {
+		return models.Todo{}, ErrTodoNotFound
+	}
+	return todo, err
+}
+
+// FindTodoForUser loads the todo with the given ID if the user owns it or it
+// has been shared with them, directly or through a todo it is a subtask of,
+// and returns the role the user holds on it.
+// Todos the user cannot see are reported as ErrTodoNotFound so that their
+// existence is not leaked.
+func FindTodoForUser(todos repositories.TodoRepository, todoID string, userID uint) (models.Todo, string, error) {
+	id, err := strconv.ParseUint(todoID, 10, 64)
+	if err != nil {
+		return models.Todo{}, "", ErrTodoNotFound
+	}
+
+	// Retreive the todo from the database
+	todo, err := todos.FindByID(uint(id), userID)
+	if errors.Is(err, repositories.ErrNotFound) {
+		return todo, "", ErrTodoNotFound
+	}
+	if err != nil {
+		return todo, "", err
+	}
+
+	// The creator always owns the todo
+	if todo.UserID == userID {
+		return todo, models.ShareRoleOwner, nil
+	}
+
+	// Otherwise the todo, or one it is a subtask of, must have been shared
+	// with the user
+	ids, err := TodoAncestorIDs(todo)
+	if err != nil {
+		return models.Todo{}, "", err
+	}
+	ids = append(ids, todo.ID)
+
+	var shares []models.TodoShare
+	if result := initializers.DB.Where("todo_id IN ? AND user_id = ?", ids, userID).Find(&shares); result.Error != nil {
+		return models.Todo{}, "", result.Error
+	}
+	if len(shares) == 0 {
+		return models.Todo{}, "", ErrTodoNotFound
+	}
+
+	// The most privileged share wins
+	role := shares[0].Role
+	for _, share := range shares[1:] {
+		if shareRoleRank[share.Role] > shareRoleRank[role] {
+			role = share.Role
+		}
+	}
+
+	return todo, role, nil
+}
+
+var shareRoleRank = map[string]int{
+	models.ShareRoleViewer: 1,
+	models.ShareRoleEditor: 2,
+	models.ShareRoleOwner:  3,
+}
+
+// CanEditTodo reports whether the role allows changing a todo.
+func CanEditTodo(role string) bool {
+	return role == models.ShareRoleEditor || role == models.ShareRoleOwner
+}
+
+// CanManageTodo reports whether the role allows deleting a todo and managing
+// who it is shared with.
+func CanManageTodo(role string) bool {
+	return role == models.ShareRoleOwner
+}
@@ -0,0 +1,150 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultTodoPageSize = 20
+	maxTodoPageSize     = 100
+	defaultTodoSort     = "-created_at"
+)
+
+// ParseTodoListQuery reads the todo list parameters from the query string.
+func ParseTodoListQuery(ctx *gin.Context, userID uint) (repositories.TodoListQuery, error) {
+	query := repositories.TodoListQuery{
+		UserID:      userID,
+		Limit:       defaultTodoPageSize,
+		Sort:        defaultTodoSort,
+		Search:      ctx.Query("q"),
+		Title:       ctx.Query("title"),
+		Description: ctx.Query("description"),
+	}
+
+	if limit := ctx.Query("limit"); limit != "" {
+		value, err := strconv.Atoi(limit)
+		if err != nil || value < 1 {
+			return query, fmt.Errorf("limit must be a positive number")
+		}
+		query.Limit = min(value, maxTodoPageSize)
+	}
+
+	if sort := ctx.Query("sort"); sort != "" {
+		if _, ok := repositories.TodoSortColumns[strings.TrimPrefix(sort, "-")]; !ok {
+			return query, fmt.Errorf("sort must be one of created_at, updated_at, title or priority, optionally prefixed with -")
+		}
+		query.Sort = sort
+	}
+
+	if completed := ctx.Query("completed"); completed != "" {
+		value, err := strconv.ParseBool(completed)
+		if err != nil {
+			return query, fmt.Errorf("completed must be true or false")
+		}
+		query.Completed = &value
+	}
+
+	dates := []struct {
+		name   string
+		target **time.Time
+	}{
+		{"created_after", &query.CreatedAfter},
+		{"created_before", &query.CreatedBefore},
+		{"updated_after", &query.UpdatedAfter},
+		{"updated_before", &query.UpdatedBefore},
+	}
+	for _, date := range dates {
+		value := ctx.Query(date.name)
+		if value == "" {
+			continue
+		}
+		parsed, err := parseQueryTime(value)
+		if err != nil {
+			return query, fmt.Errorf("%s must be an RFC 3339 timestamp or a YYYY-MM-DD date", date.name)
+		}
+		*date.target = &parsed
+	}
+
+	// Tags are matched by name, either any of them or all of them
+	for _, tag := range ctx.QueryArray("tag") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			query.Tags = append(query.Tags, tag)
+		}
+	}
+	switch mode := ctx.DefaultQuery("tag_mode", "any"); mode {
+	case "any":
+	case "all":
+		query.MatchAllTags = true
+	default:
+		return query, fmt.Errorf("tag_mode must be any or all")
+	}
+
+	// priority=high,urgent picks exact priorities, while priority>=high and
+	// priority<=medium (also min_priority and max_priority) pick a range
+	if priorities := ctx.Query("priority"); priorities != "" {
+		for _, name := range strings.Split(priorities, ",") {
+			priority, err := models.ParsePriority(strings.TrimSpace(name))
+			if err != nil {
+				return query, err
+			}
+			query.Priorities = append(query.Priorities, priority)
+		}
+	}
+	bounds := []struct {
+		names  []string
+		target *models.Priority
+	}{
+		{[]string{"priority>", "min_priority"}, &query.MinPriority},
+		{[]string{"priority<", "max_priority"}, &query.MaxPriority},
+	}
+	for _, bound := range bounds {
+		for _, name := range bound.names {
+			value := ctx.Query(name)
+			if value == "" {
+				continue
+			}
+			priority, err := models.ParsePriority(value)
+			if err != nil {
+				return query, err
+			}
+			*bound.target = priority
+		}
+	}
+
+	// project_id=none picks the todos outside of any project
+	if project := ctx.Query("project_id"); project == "none" {
+		query.NoProject = true
+	} else if project != "" {
+		id, err := strconv.ParseUint(project, 10, 64)
+		if err != nil {
+			return query, fmt.Errorf("project_id must be a project ID or none")
+		}
+		projectID := uint(id)
+		query.ProjectID = &projectID
+	}
+
+	if cursor := ctx.Query("cursor"); cursor != "" {
+		decoded, err := repositories.DecodeTodoCursor(cursor)
+		if err != nil || decoded.Sort != query.Sort {
+			return query, fmt.Errorf("invalid cursor")
+		}
+		query.Cursor = &decoded
+	}
+
+	return query, nil
+}
+
+// parseQueryTime accepts either a full RFC 3339 timestamp or a plain date.
+func parseQueryTime(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
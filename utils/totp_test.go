@@ -0,0 +1,62 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the SHA1 key of the RFC 6238 test vectors,
+// "12345678901234567890" in base32.
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestTOTPCode(t *testing.T) {
+	// RFC 6238 appendix B, keeping the last six of the eight digits
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, test := range tests {
+		code, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(test.unix, 0)))
+		if err != nil || code != test.want {
+			t.Errorf("code at %d = %q, %v, want %q", test.unix, code, err, test.want)
+		}
+	}
+
+	if _, err := TOTPCode("not base32!", 1); err == nil {
+		t.Error("TOTPCode accepted an invalid secret")
+	}
+}
+
+func TestValidateTOTP(t *testing.T) {
+	issuedAt := time.Unix(1111111111, 0)
+	step := TOTPStep(issuedAt)
+	code, err := TOTPCode(rfc6238Secret, step)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The code is accepted one step either side of the clock, and no further
+	for offset := int64(-2); offset <= 2; offset++ {
+		matched, ok := ValidateTOTP(rfc6238Secret, code, issuedAt.Add(time.Duration(offset*totpPeriod)*time.Second))
+		if want := offset >= -totpSkew && offset <= totpSkew; ok != want || (ok && matched != step) {
+			t.Errorf("clock %d steps off: matched step %d, %v, want %v", offset, matched, ok, want)
+		}
+	}
+
+	if _, ok := ValidateTOTP(rfc6238Secret, " 050 471 ", issuedAt); !ok {
+		t.Error("code with spaces was refused")
+	}
+	for _, invalid := range []string{"", "05047", "0504710", "050472"} {
+		if _, ok := ValidateTOTP(rfc6238Secret, invalid, issuedAt); ok {
+			t.Errorf("code %q was accepted", invalid)
+		}
+	}
+}
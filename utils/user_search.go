@@ -0,0 +1,84 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 200
+)
+
+// UserSearchQuery holds the filters for the admin user listing.
+type UserSearchQuery struct {
+	Search   string
+	Role     string
+	Disabled *bool
+	Limit    int
+	Offset   int
+}
+
+// ParseUserSearchQuery reads q, role, disabled, limit and offset from the
+// query string.
+func ParseUserSearchQuery(ctx *gin.Context) (UserSearchQuery, error) {
+	query := UserSearchQuery{
+		Search: ctx.Query("q"),
+		Role:   ctx.Query("role"),
+		Limit:  defaultUserPageSize,
+	}
+
+	if query.Role != "" && !models.IsValidRole(query.Role) {
+		return query, fmt.Errorf("role must be one of user, support, admin")
+	}
+
+	if raw := ctx.Query("disabled"); raw != "" {
+		disabled, err := strconv.ParseBool(raw)
+		if err != nil {
+			return query, fmt.Errorf("disabled must be true or false")
+		}
+		query.Disabled = &disabled
+	}
+
+	if raw := ctx.Query("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit < 1 || limit > maxUserPageSize {
+			return query, fmt.Errorf("limit must be between 1 and %d", maxUserPageSize)
+		}
+		query.Limit = limit
+	}
+
+	if raw := ctx.Query("offset"); raw != "" {
+		offset, err := strconv.Atoi(raw)
+		if err != nil || offset < 0 {
+			return query, fmt.Errorf("offset must be a positive number")
+		}
+		query.Offset = offset
+	}
+
+	return query, nil
+}
+
+// Filter applies the search, role and disabled filters.
+func (query UserSearchQuery) Filter(db *gorm.DB) *gorm.DB {
+	if query.Search != "" {
+		pattern := repositories.LikePattern(query.Search)
+		db = db.Where(`LOWER(name) LIKE ? ESCAPE '\' OR LOWER(user_name) LIKE ? ESCAPE '\' OR LOWER(email) LIKE ? ESCAPE '\'`, pattern, pattern, pattern)
+	}
+	if query.Role != "" {
+		db = db.Where("role = ?", query.Role)
+	}
+	if query.Disabled != nil {
+		if *query.Disabled {
+			db = db.Where("disabled_at IS NOT NULL")
+		} else {
+			db = db.Where("disabled_at IS NULL")
+		}
+	}
+	return db
+}
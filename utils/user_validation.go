@@ -2,15 +2,19 @@ package utils
 
 import (
 	"fmt"
-	"os"
+	"net/mail"
+	"slices"
 	"time"
 
 	"github.com/Waris-Shaik/todo-backend/initializers"
 	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/Waris-Shaik/todo-backend/repositories"
 	"github.com/golang-jwt/jwt/v5"
-	"golang.org/x/crypto/bcrypt"
 )
 
+// mfaTokenTTL is how long a user has to enter their two-factor code.
+const mfaTokenTTL = 5 * time.Minute
+
 func ValidateUserData(user *models.User) error {
 	if user.Name == "" || user.UserName == "" || user.Email == "" || user.Password == "" {
 		return fmt.Errorf("please fill all required fields")
@@ -18,69 +22,149 @@ func ValidateUserData(user *models.User) error {
 	return nil
 }
 
-func ValidatePassword(user *models.User) error {
-
-	const (
-		minPasswordLength = 6
-		maxPsswordLength  = 13
-	)
-
-	if len(user.Password) < minPasswordLength || len(user.Password) > maxPsswordLength {
-		if len(user.Password) < minPasswordLength {
-
-			return fmt.Errorf("password should contain at least 6 characters")
-		} else {
-
-			return fmt.Errorf("password must not be greater than 13 characters")
-		}
+// ValidateEmail checks the email is a bare address such as
+// alice@example.com, without a display name or angle brackets.
+func ValidateEmail(email string) error {
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || address.Name != "" {
+		return fmt.Errorf("please provide a valid email address")
 	}
-
 	return nil
 }
 
-func CheckExistingUser(email string) error {
+func CheckExistingUser(users repositories.UserRepository, email string) error {
 	// Retreive hthe user from the database
-	var existingUser models.User
-	if result := initializers.DB.Where("email = ?", email).First(&existingUser); result.Error == nil {
+	if _, err := users.FindByEmail(email); err == nil {
 		return fmt.Errorf("user already exists please login")
 	}
 
 	return nil
 }
 
-func CreateUser(user *models.User) error {
-	result := initializers.DB.Create(user)
-	if result.Error != nil {
-		return result.Error
-	}
-	return nil
+func CreateUser(users repositories.UserRepository, user *models.User) error {
+	return users.Create(user)
 }
 
-func GenerateToken(user *models.User) (string, error) {
-	// Generate a jwt
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+func GenerateToken(user *models.User, sessionID uint) (string, error) {
+	// Generate a short lived access jwt tied to the session
+	return signToken(jwt.MapClaims{
 		"_id": user.ID,
-		"exp": time.Now().Add(time.Minute * 30).Unix(), // Token expiration time
+		"sid": sessionID,
+		"typ": "access",
+		"exp": time.Now().Add(AccessTokenTTL()).Unix(), // Token expiration time
 	})
+}
 
-	// Get JWT secret key
-	secretKey := []byte(os.Getenv("JWT_SECRET_KEY"))
-	if len(secretKey) == 0 {
-		return "", fmt.Errorf("jwt secret key not found")
+// GenerateMFAToken issues the short lived token a user with two-factor
+// authentication gets after their password checks out. It only proves the
+// first step and is exchanged for a session along with a code.
+func GenerateMFAToken(user *models.User) (string, error) {
+	return signToken(jwt.MapClaims{
+		"_id": user.ID,
+		"typ": "mfa",
+		"exp": time.Now().Add(mfaTokenTTL).Unix(),
+	})
+}
+
+// signToken signs with the active signing key, or with JWT_SECRET_KEY when
+// JWT_SIGNING_ALG is HS256. Every token names this server as its issuer and
+// the API as its audience.
+func signToken(claims jwt.MapClaims) (string, error) {
+	claims["iss"] = initializers.Config.Auth.JWTIssuer
+	claims["aud"] = initializers.Config.Auth.JWTAudience
+
+	if SigningAlgorithm() == "HS256" {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+
+		// Get JWT secret key
+		secretKey := []byte(initializers.Config.Auth.JWTSecretKey)
+		if len(secretKey) == 0 {
+			return "", fmt.Errorf("jwt secret key not found")
+		}
+
+		// Sign and get the encoded token as a string usig the jwt_secret
+		return token.SignedString(secretKey)
 	}
 
-	// Sign and get the encoded token as a string usig the jwt_secret
-	return token.SignedString(secretKey)
+	key, err := keys.active()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.record.Kid
+	return token.SignedString(key.private)
+}
 
+// ParseToken verifies an access token and returns its claims. Expiry is
+// skipped when checkExpiry is false, which lets logout identify the session of
+// a token that has already run out.
+func ParseToken(tokenString string, checkExpiry bool) (jwt.MapClaims, error) {
+	return parseToken(tokenString, "access", checkExpiry)
 }
 
-func IsPasswordMatches(userPassword *string, existingUserPassword *string) error {
+// ParseMFAToken verifies a token from GenerateMFAToken.
+func ParseMFAToken(tokenString string) (jwt.MapClaims, error) {
+	return parseToken(tokenString, "mfa", true)
+}
 
-	err := bcrypt.CompareHashAndPassword([]byte(*existingUserPassword), []byte(*userPassword))
-	if err != nil {
-		return fmt.Errorf("password does not matches")
+// parseToken verifies tokens signed by any published signing key, issued by
+// this server for the API. After switching from HS256, tokens signed with
+// JWT_SECRET_KEY are only accepted while ACCEPT_LEGACY_HS256 is set, so
+// sessions from before the switch keep working until it is turned off. They
+// predate the iss and aud claims and are accepted without them.
+func parseToken(tokenString string, typ string, checkExpiry bool) (jwt.MapClaims, error) {
+	legacyHS256 := SigningAlgorithm() != "HS256"
+	options := []jwt.ParserOption{jwt.WithValidMethods([]string{"RS256", "EdDSA", "HS256"})}
+	if !checkExpiry {
+		options = append(options, jwt.WithoutClaimsValidation())
 	}
 
-	return nil
+	claims := jwt.MapClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method.Alg() == "HS256" {
+			secretKey := initializers.Config.Auth.JWTSecretKey
+			if secretKey == "" || (legacyHS256 && !initializers.Config.Auth.AcceptLegacyHS256) {
+				return nil, fmt.Errorf("hs256 tokens are not accepted")
+			}
+			return []byte(secretKey), nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, err := keys.verifier(kid)
+		if err != nil {
+			return nil, err
+		}
+		if key.method.Alg() != token.Method.Alg() {
+			return nil, fmt.Errorf("token algorithm does not match its key")
+		}
+		return key.public, nil
+	}, options...)
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	// Tokens are only accepted where their type is expected
+	if claimed, _ := claims["typ"].(string); claimed != typ {
+		return nil, fmt.Errorf("invalid token")
+	}
 
+	// Tokens meant for another service are not accepted here
+	if !legacyHS256 || token.Method.Alg() != "HS256" {
+		issuer, _ := claims.GetIssuer()
+		audience, _ := claims.GetAudience()
+		if issuer != initializers.Config.Auth.JWTIssuer || !slices.Contains(audience, initializers.Config.Auth.JWTAudience) {
+			return nil, fmt.Errorf("invalid token")
+		}
+	}
+
+	return claims, nil
+}
+
+// ClaimID reads a numeric claim such as "_id" or "sid".
+func ClaimID(claims jwt.MapClaims, name string) (uint, bool) {
+	value, ok := claims[name].(float64)
+	if !ok || value <= 0 {
+		return 0, false
+	}
+	return uint(value), true
 }
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Waris-Shaik/todo-backend/config"
+	"github.com/Waris-Shaik/todo-backend/initializers"
+	"github.com/Waris-Shaik/todo-backend/models"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// useAuth switches the token settings for the rest of the test.
+func useAuth(t *testing.T, change func(auth *config.Auth)) {
+	t.Helper()
+	previous := initializers.Config.Auth
+	auth := config.Default().Auth
+	auth.SigningAlgorithm = "HS256"
+	auth.JWTSecretKey = "test-secret"
+	change(&auth)
+	initializers.Config.Auth = auth
+	t.Cleanup(func() { initializers.Config.Auth = previous })
+}
+
+// signHS256 signs claims with JWT_SECRET_KEY, like tokens issued before the
+// iss and aud claims were.
+func signHS256(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(initializers.Config.Auth.JWTSecretKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestTokenIssuerAndAudience(t *testing.T) {
+	useAuth(t, func(auth *config.Auth) {})
+
+	token, err := GenerateToken(&models.User{ID: 7}, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ParseToken(token, true)
+	if err != nil {
+		t.Fatalf("ParseToken: %v", err)
+	}
+	if claims["iss"] != "todo-backend" || claims["aud"] != "todo-api" {
+		t.Fatalf("claims = %v, want this server's issuer and audience", claims)
+	}
+
+	// Tokens for another audience or from another issuer are refused, even
+	// when signed with the same key
+	exp := time.Now().Add(time.Minute).Unix()
+	for name, claims := range map[string]jwt.MapClaims{
+		"other audience": {"_id": 7, "sid": 3, "typ": "access", "exp": exp, "iss": "todo-backend", "aud": "reports"},
+		"other issuer":   {"_id": 7, "sid": 3, "typ": "access", "exp": exp, "iss": "someone-else", "aud": "todo-api"},
+		"no claims":      {"_id": 7, "sid": 3, "typ": "access", "exp": exp},
+	} {
+		if _, err := ParseToken(signHS256(t, claims), true); err == nil {
+			t.Errorf("token with %s was accepted", name)
+		}
+	}
+}
+
+func TestLegacyHS256Tokens(t *testing.T) {
+	legacy := jwt.MapClaims{"_id": 7, "sid": 3, "typ": "access", "exp": time.Now().Add(time.Minute).Unix()}
+
+	// After switching to asymmetric keys, HS256 tokens are refused unless
+	// explicitly accepted
+	useAuth(t, func(auth *config.Auth) { auth.SigningAlgorithm = "EdDSA" })
+	if _, err := ParseToken(signHS256(t, legacy), true); err == nil {
+		t.Fatal("legacy HS256 token accepted without ACCEPT_LEGACY_HS256")
+	}
+
+	useAuth(t, func(auth *config.Auth) {
+		auth.SigningAlgorithm = "EdDSA"
+		auth.AcceptLegacyHS256 = true
+	})
+	if _, err := ParseToken(signHS256(t, legacy), true); err != nil {
+		t.Fatalf("legacy HS256 token refused with ACCEPT_LEGACY_HS256: %v", err)
+	}
+}